@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // -tz must work on hosts without a zoneinfo database
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/export"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+func customerCreate(e *env, args []string) error {
+	flags := newFlags("customer create")
+	var customer model.Customer
+	flags.StringVar(&customer.Name, "name", "", "customer name")
+	flags.Var(moneyFlag{&customer.Balance}, "balance", "opening balance")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := customer.Validate(); err != nil {
+		return err
+	}
+	id, status := e.s.Customer.Create(e.ctx, customer)
+	if err := check(status); err != nil {
+		return err
+	}
+	customer, status = e.s.Customer.GetByID(e.ctx, id)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(customer)
+}
+
+func customerList(e *env, args []string) error {
+	flags := newFlags("customer list")
+	limit := flags.Int("limit", 50, "page size, 0 lists every customer")
+	offset := flags.Int("offset", 0, "customers to skip")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	customers, status := e.s.Customer.GetAll(e.ctx, *limit, *offset)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(customers)
+}
+
+func customerGet(e *env, args []string) error {
+	flags := newFlags("customer get")
+	id := flags.Int("id", 0, "customer id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	customer, status := e.s.Customer.GetByID(e.ctx, *id)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(customer)
+}
+
+// balanceResult is what deposit and withdraw print
+type balanceResult struct {
+	CustomerID int         `json:"customer_id"`
+	Balance    model.Money `json:"balance"`
+}
+
+func customerDeposit(e *env, args []string) error {
+	operation, err := parseBalanceOperation("customer deposit", args)
+	if err != nil {
+		return err
+	}
+	balance, status := e.s.Customer.Deposit(e.ctx, operation)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(balanceResult{CustomerID: operation.CustomerID, Balance: balance})
+}
+
+func customerWithdraw(e *env, args []string) error {
+	operation, err := parseBalanceOperation("customer withdraw", args)
+	if err != nil {
+		return err
+	}
+	balance, status := e.s.Customer.Withdraw(e.ctx, operation)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(balanceResult{CustomerID: operation.CustomerID, Balance: balance})
+}
+
+func parseBalanceOperation(name string, args []string) (model.BalanceOperation, error) {
+	flags := newFlags(name)
+	var operation model.BalanceOperation
+	flags.IntVar(&operation.CustomerID, "id", 0, "customer id")
+	flags.Var(moneyFlag{&operation.Amount}, "amount", "amount, greater than zero")
+	flags.StringVar(&operation.Reason, "reason", "", "reason written to the ledger")
+	if err := flags.Parse(args); err != nil {
+		return model.BalanceOperation{}, err
+	}
+	switch {
+	case operation.Amount <= 0:
+		return model.BalanceOperation{}, errors.New("amount must be greater than zero")
+	case operation.Reason == "":
+		return model.BalanceOperation{}, errors.New("reason is required")
+	}
+	return operation, nil
+}
+
+func customerLedger(e *env, args []string) error {
+	flags := newFlags("customer ledger")
+	id := flags.Int("id", 0, "customer id")
+	limit := flags.Int("limit", 50, "page size, 0 lists every entry")
+	offset := flags.Int("offset", 0, "entries to skip")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	entries, status := e.s.Ledger.GetByCustomerID(e.ctx, *id, *limit, *offset)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(entries)
+}
+
+func transactionGet(e *env, args []string) error {
+	flags := newFlags("transaction get")
+	id := flags.Int("id", 0, "transaction id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	transaction, status := e.s.Transaction.GetByTransactionID(e.ctx, *id)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(transaction)
+}
+
+// voidResult is what void prints, a voided transaction is deleted and can't be read back
+type voidResult struct {
+	TransactionID int    `json:"transaction_id"`
+	Reason        string `json:"reason"`
+	Result        string `json:"result"`
+}
+
+func transactionVoid(e *env, args []string) error {
+	flags := newFlags("transaction void")
+	id := flags.Int("id", 0, "transaction id")
+	reason := flags.String("reason", "voided by "+defaultOperator(), "reason written to the ledger")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	status := e.s.Transaction.Delete(e.ctx, *id, *reason)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(voidResult{TransactionID: *id, Reason: *reason, Result: status.Msg})
+}
+
+// parseTransactionFilter reads the search flags shared by transaction search and export
+func parseTransactionFilter(flags *flag.FlagSet, filter *model.TransactionFilter) {
+	flags.StringVar(&filter.CustomerName, "customer-name", "", "part of the customer name")
+	flags.StringVar(&filter.ItemName, "item-name", "", "part of the item name")
+	flags.Var(timeFlag{t: &filter.CreatedFrom}, "from", "created at or after, RFC3339 or YYYY-MM-DD")
+	flags.Var(timeFlag{t: &filter.CreatedTo, endOfDay: true}, "to", "created at or before, RFC3339 or YYYY-MM-DD")
+}
+
+func transactionSearch(e *env, args []string) error {
+	flags := newFlags("transaction search")
+	filter := model.TransactionFilter{}
+	parseTransactionFilter(flags, &filter)
+	flags.IntVar(&filter.Limit, "limit", 50, "page size")
+	flags.IntVar(&filter.Offset, "offset", 0, "rows to skip")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	page, status := e.s.Transaction.GetAllTransactionViewsByFilters(e.ctx, &filter)
+	if err := check(status); err != nil {
+		return err
+	}
+	if e.output == outputJSON {
+		return e.print(page)
+	}
+	return e.print(page.Items)
+}
+
+func parseReportFilter(name string, args []string, groupBy bool) (model.ReportFilter, error) {
+	flags := newFlags(name)
+	filter := model.ReportFilter{}
+	flags.Var(timeFlag{t: &filter.From}, "from", "sales created at or after, RFC3339 or YYYY-MM-DD")
+	flags.Var(timeFlag{t: &filter.To, endOfDay: true}, "to", "sales created at or before, RFC3339 or YYYY-MM-DD")
+	if groupBy {
+		flags.StringVar(&filter.GroupBy, "group-by", model.ReportGroupDay, "period, day, week or month")
+	}
+	err := flags.Parse(args)
+	return filter, err
+}
+
+func reportSales(e *env, args []string) error {
+	filter, err := parseReportFilter("report sales", args, true)
+	if err != nil {
+		return err
+	}
+	rows, status := e.s.Report.Sales(e.ctx, filter)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(rows)
+}
+
+func reportItems(e *env, args []string) error {
+	filter, err := parseReportFilter("report items", args, false)
+	if err != nil {
+		return err
+	}
+	rows, status := e.s.Report.Items(e.ctx, filter)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(rows)
+}
+
+func reportCustomers(e *env, args []string) error {
+	filter, err := parseReportFilter("report customers", args, false)
+	if err != nil {
+		return err
+	}
+	rows, status := e.s.Report.Customers(e.ctx, filter)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(rows)
+}
+
+// exportTransactions writes the transaction search to a file or stdout, -o does not apply to it
+func exportTransactions(e *env, args []string) error {
+	flags := newFlags("export transactions")
+	filter := model.TransactionFilter{}
+	parseTransactionFilter(flags, &filter)
+	format := flags.String("format", export.FormatCSV, "csv or xlsx")
+	out := flags.String("out", "", "output file, stdout by default")
+	columns := flags.String("columns", strings.Join(export.TransactionViewColumns, ","), "comma separated columns")
+	tz := flags.String("tz", "UTC", "IANA time zone of the timestamps")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, ok := export.ContentTypes[*format]; !ok {
+		return fmt.Errorf("format must be %s or %s", export.FormatCSV, export.FormatXLSX)
+	}
+	names := strings.Split(*columns, ",")
+	for _, column := range names {
+		if !export.IsTransactionViewColumn(column) {
+			return errors.New("unknown column " + column)
+		}
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return fmt.Errorf("tz is invalid time zone: %w", err)
+	}
+	stream, status := e.s.Transaction.ExportTransactionViews(&filter)
+	if err := check(status); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return writeTransactionViews(e, os.Stdout, stream, *format, names, loc)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	err = writeTransactionViews(e, file, stream, *format, names, loc)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		return fmt.Errorf("file.Close: %w", closeErr)
+	}
+	return err
+}
+
+// writeTransactionViews buffers w, the export writes it row by row
+func writeTransactionViews(
+	e *env,
+	w io.Writer,
+	stream service.TransactionViewStream,
+	format string,
+	names []string,
+	loc *time.Location,
+) error {
+	buffered := bufio.NewWriter(w)
+	if err := export.WriteTransactionViews(e.ctx, buffered, stream, format, names, loc); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// platformFlag adds -platform, the returned func gives the context of the tenant keys or, with
+// the flag, of the platform keys that aren't bound to a tenant
+func platformFlag(e *env, flags *flag.FlagSet) func() context.Context {
+	platform := flags.Bool("platform", false, "work on the platform keys that pick the tenant with X-Tenant-ID")
+	return func() context.Context {
+		if *platform {
+			return tenant.WithID(e.ctx, 0)
+		}
+		return e.ctx
+	}
+}
+
+// apiKeyCreate prints the key once, it is the way to get the first admin key
+func apiKeyCreate(e *env, args []string) error {
+	flags := newFlags("apikey create")
+	var key model.APIKey
+	flags.StringVar(&key.Name, "name", "", "name of the key")
+	flags.StringVar(&key.Scope, "scope", model.APIKeyScopeReadOnly, "read_only, cashier or admin")
+	ctx := platformFlag(e, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	created, status := e.s.APIKey.Create(ctx(), key)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(created)
+}
+
+func apiKeyList(e *env, args []string) error {
+	flags := newFlags("apikey list")
+	ctx := platformFlag(e, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	keys, status := e.s.APIKey.GetAll(ctx())
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(keys)
+}
+
+func apiKeyRevoke(e *env, args []string) error {
+	flags := newFlags("apikey revoke")
+	id := flags.Int("id", 0, "api key id")
+	ctx := platformFlag(e, flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	key, status := e.s.APIKey.Revoke(ctx(), *id)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(key)
+}
+
+// userCreate is the way to get the first admin user
+func userCreate(e *env, args []string) error {
+	flags := newFlags("user create")
+	var user model.User
+	flags.StringVar(&user.Username, "username", "", "login name")
+	password := flags.String("password", "", "password, at least 8 characters")
+	flags.StringVar(&user.Role, "role", model.RoleCashier, "auditor, cashier, manager or admin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	created, status := e.s.User.Create(e.ctx, user, *password)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(created)
+}
+
+func userList(e *env, args []string) error {
+	if err := newFlags("user list").Parse(args); err != nil {
+		return err
+	}
+	users, status := e.s.User.GetAll(e.ctx)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(users)
+}
+
+// userUpdate changes only the flags that are given
+func userUpdate(e *env, args []string) error {
+	flags := newFlags("user update")
+	id := flags.Int("id", 0, "user id")
+	role := flags.String("role", "", "auditor, cashier, manager or admin")
+	password := flags.String("password", "", "new password")
+	disabled := flags.Bool("disabled", false, "disable or enable the login")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	update := model.UserUpdate{ID: *id}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "role":
+			update.Role = role
+		case "password":
+			update.Password = password
+		case "disabled":
+			update.Disabled = disabled
+		}
+	})
+	user, status := e.s.User.Update(e.ctx, update)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(user)
+}
+
+func tenantCreate(e *env, args []string) error {
+	flags := newFlags("tenant create")
+	var t model.Tenant
+	flags.StringVar(&t.Slug, "slug", "", "short unique name, lowercase letters, digits and dashes")
+	flags.StringVar(&t.Name, "name", "", "display name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	created, status := e.s.Tenant.Create(e.ctx, t)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(created)
+}
+
+func tenantList(e *env, args []string) error {
+	if err := newFlags("tenant list").Parse(args); err != nil {
+		return err
+	}
+	tenants, status := e.s.Tenant.GetAll(e.ctx)
+	if err := check(status); err != nil {
+		return err
+	}
+	return e.print(tenants)
+}
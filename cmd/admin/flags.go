@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+// newFlags returns the flag set of a command, its usage line comes from the commands table
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: admin %s %s\n", name, commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// moneyFlag is a model.Money flag parsed with model.ParseMoney
+type moneyFlag struct {
+	m *model.Money
+}
+
+func (f moneyFlag) String() string {
+	if f.m == nil {
+		return "0.00"
+	}
+	return f.m.String()
+}
+
+func (f moneyFlag) Set(s string) error {
+	m, err := model.ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*f.m = m
+	return nil
+}
+
+// timeFlag takes RFC3339 times or dates like the HTTP API does, a date given as an end
+// of a range means the end of that day
+type timeFlag struct {
+	t        **time.Time
+	endOfDay bool
+}
+
+func (f timeFlag) String() string {
+	if f.t == nil || *f.t == nil {
+		return ""
+	}
+	return (*f.t).Format(time.RFC3339)
+}
+
+func (f timeFlag) Set(s string) error {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, s)
+		if err != nil {
+			return errors.New("expected an RFC3339 time or a YYYY-MM-DD date")
+		}
+		if f.endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+	*f.t = &t
+	return nil
+}
+
+// defaultOperator is the os user the cli acts as, it is written to the ledger as cli:<name>
+func defaultOperator() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "admin"
+}
@@ -0,0 +1,144 @@
+// Command admin runs day-to-day operations against the database of the app,
+// it reads the same config as cmd/app.
+//
+//	admin [-o json|table] [-tenant ID] COMMAND [FLAGS]
+//
+// Run admin -h for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/robertt3kuk/xiaoma-test-task/config"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/app"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+// env is what a command runs with
+type env struct {
+	ctx    context.Context
+	cfg    *config.Config
+	s      *service.Service
+	output string
+}
+
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+// commands is filled in init, the commands read their own usage from it
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"customer create":    {usage: "-name NAME -balance AMOUNT", run: customerCreate},
+		"customer list":      {usage: "[-limit N] [-offset N]", run: customerList},
+		"customer get":       {usage: "-id ID", run: customerGet},
+		"customer deposit":   {usage: "-id ID -amount AMOUNT -reason REASON", run: customerDeposit},
+		"customer withdraw":  {usage: "-id ID -amount AMOUNT -reason REASON", run: customerWithdraw},
+		"customer ledger":    {usage: "-id ID [-limit N] [-offset N]", run: customerLedger},
+		"transaction get":    {usage: "-id ID", run: transactionGet},
+		"transaction void":   {usage: "-id ID [-reason REASON]", run: transactionVoid},
+		"transaction search": {usage: "[-customer-name S] [-item-name S] [-from DATE] [-to DATE] [-limit N]", run: transactionSearch},
+		"report sales":       {usage: "[-from DATE] [-to DATE] [-group-by day|week|month]", run: reportSales},
+		"report items":       {usage: "[-from DATE] [-to DATE]", run: reportItems},
+		"report customers":   {usage: "[-from DATE] [-to DATE]", run: reportCustomers},
+		"export transactions": {
+			usage: "[-format csv|xlsx] [-out FILE] [-columns a,b] [-tz ZONE] [-from DATE] [-to DATE]",
+			run:   exportTransactions,
+		},
+		"apikey create": {usage: "-name NAME -scope read_only|cashier|admin [-platform]", run: apiKeyCreate},
+		"apikey list":   {usage: "[-platform]", run: apiKeyList},
+		"apikey revoke": {usage: "-id ID [-platform]", run: apiKeyRevoke},
+		"user create":   {usage: "-username NAME -password PASSWORD -role auditor|cashier|manager|admin", run: userCreate},
+		"user list":     {usage: "", run: userList},
+		"user update":   {usage: "-id ID [-role ROLE] [-password PASSWORD] [-disabled true|false]", run: userUpdate},
+		"tenant create": {usage: "-slug SLUG -name NAME", run: tenantCreate},
+		"tenant list":   {usage: "", run: tenantList},
+	}
+}
+
+func main() {
+	err := run(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("admin", flag.ContinueOnError)
+	output := flags.String("o", outputTable, "output format, json or table")
+	tenantID := flags.Int("tenant", tenant.Default, "id of the tenant the command works on")
+	flags.Usage = usage
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("unknown output %q, expected json or table", *output)
+	}
+	if *tenantID <= 0 {
+		return fmt.Errorf("unknown tenant %d, expected a positive id", *tenantID)
+	}
+	args = flags.Args()
+
+	// commands are one or two words long
+	name, cmd, ok := "", command{}, false
+	if len(args) > 1 {
+		name = args[0] + " " + args[1]
+		cmd, ok = commands[name]
+	}
+	if ok {
+		args = args[2:]
+	} else if len(args) > 0 {
+		name = args[0]
+		cmd, ok = commands[name]
+		args = args[1:]
+	}
+	if !ok {
+		usage()
+		return errors.New("admin: unknown command")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return fmt.Errorf("admin - config.NewConfig: %w", err)
+	}
+	// the cli acts as an admin, changes are recorded under the name of the os user
+	actor := model.Actor{Name: "cli:" + defaultOperator(), Permissions: model.RolePermissions[model.RoleAdmin]}
+	ctx := tenant.WithID(service.WithActor(context.Background(), actor), *tenantID)
+	e := &env{ctx: ctx, cfg: cfg, output: *output}
+
+	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PoolMax))
+	if err != nil {
+		return fmt.Errorf("admin - postgres.New: %w", err)
+	}
+	defer pg.Close()
+	e.s = app.NewService(cfg, pg)
+	return cmd.run(e, args)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin [-o json|table] [-tenant ID] COMMAND [FLAGS]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", name, commands[name].usage)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// print writes v to stdout as indented JSON or as a table, slices of structs are written one
+// row per element and a single struct as one row, the columns are the json names of the fields
+func (e *env) print(v any) error {
+	if e.output == outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	return printTable(os.Stdout, v)
+}
+
+// check turns a failed service call into an error
+func check(status service.Status) error {
+	if status.Ok() {
+		return nil
+	}
+	return fmt.Errorf("%s (%d): %w", status.Msg, status.Code, status.Err)
+}
+
+func printTable(w io.Writer, v any) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	var rows []reflect.Value
+	t := value.Type()
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, reflect.Indirect(value.Index(i)))
+		}
+		t = t.Elem()
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	default:
+		rows = []reflect.Value{value}
+	}
+	if t.Kind() != reflect.Struct {
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(w, formatCell(row)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	fields, header := tableColumns(t)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(fields))
+		for i, field := range fields {
+			cells[i] = formatCell(row.FieldByIndex(field))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// tableColumns returns the index paths and the upper-cased json names of the exported fields
+// of t, embedded structs are flattened, fields tagged json:"-" and nested slices are left out
+func tableColumns(t reflect.Type) ([][]int, []string) {
+	var fields [][]int
+	var header []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() == reflect.Slice {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded, names := tableColumns(field.Type)
+			for _, index := range embedded {
+				fields = append(fields, append([]int{i}, index...))
+			}
+			header = append(header, names...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, []int{i})
+		header = append(header, strings.ToUpper(name))
+	}
+	return fields, header
+}
+
+func formatCell(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "-"
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case fmt.Stringer:
+		return x.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
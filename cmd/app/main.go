@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/robertt3kuk/xiaoma-test-task/config"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/app"
 )
@@ -10,5 +13,20 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			err = app.Import(cfg, os.Args[2:])
+		case "migrate":
+			err = app.Migrate(cfg, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	app.Run(cfg)
 }
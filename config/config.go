@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -11,10 +12,13 @@ type (
 
 	// Config -.
 	Config struct {
-		App  `yaml:"app"`
-		HTTP `yaml:"http"`
-		Log  `yaml:"logger"`
-		PG   `yaml:"postgres"`
+		App         `yaml:"app"`
+		HTTP        `yaml:"http"`
+		Log         `yaml:"logger"`
+		PG          `yaml:"postgres"`
+		Trace       `yaml:"trace"`
+		Auth        `yaml:"auth"`
+		Idempotency `yaml:"idempotency"`
 	}
 
 	// App -.
@@ -26,6 +30,10 @@ type (
 	// HTTP -.
 	HTTP struct {
 		Port string `env-required:"true" yaml:"port" env:"HTTP_PORT"`
+		// DrainTimeout is how long readiness fails before the server stops on shutdown
+		DrainTimeout time.Duration `yaml:"drain_timeout" env:"HTTP_DRAIN_TIMEOUT" env-default:"5s"`
+		// ExportTimeout bounds a streamed export, the query is cancelled and the file cut short after it
+		ExportTimeout time.Duration `yaml:"export_timeout" env:"HTTP_EXPORT_TIMEOUT" env-default:"10m"`
 	}
 
 	// Log -.
@@ -37,9 +45,37 @@ type (
 	PG struct {
 		PoolMax int    `env-required:"true" yaml:"pool_max" env:"PG_POOL_MAX"`
 		URL     string `env-required:"true"                 env:"PG_URL"`
+		// AutoMigrate applies the embedded migrations on startup
+		AutoMigrate bool `yaml:"auto_migrate" env:"PG_AUTO_MIGRATE" env-default:"false"`
+	}
+
+	// Trace -.
+	Trace struct {
+		// Exporter is otlp, stdout or none
+		Exporter string `yaml:"exporter" env:"TRACE_EXPORTER" env-default:"none"`
+		// Endpoint is the OTLP/HTTP collector URL, the OTEL_EXPORTER_OTLP_* variables apply when it is empty
+		Endpoint    string  `yaml:"endpoint"     env:"TRACE_ENDPOINT"`
+		SampleRatio float64 `yaml:"sample_ratio" env:"TRACE_SAMPLE_RATIO" env-default:"1"`
+	}
+
+	// Auth -.
+	Auth struct {
+		// JWTSecret signs the login tokens, it must be at least MinJWTSecretLength bytes
+		JWTSecret string        `env-required:"true"    env:"AUTH_JWT_SECRET"`
+		TokenTTL  time.Duration `yaml:"token_ttl" env:"AUTH_TOKEN_TTL" env-default:"12h"`
+	}
+
+	// Idempotency -.
+	Idempotency struct {
+		// TTL is how long an Idempotency-Key replays its first response, expired keys are purged every PurgeInterval
+		TTL           time.Duration `yaml:"ttl"            env:"IDEMPOTENCY_TTL"            env-default:"24h"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL" env-default:"1h"`
 	}
 )
 
+// MinJWTSecretLength is the shortest AUTH_JWT_SECRET accepted, 32 bytes is the size of the HS256 hash
+const MinJWTSecretLength = 32
+
 // NewConfig returns app config.
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
@@ -53,5 +89,8 @@ func NewConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(cfg.Auth.JWTSecret) < MinJWTSecretLength {
+		return nil, fmt.Errorf("config error: AUTH_JWT_SECRET must be at least %d bytes", MinJWTSecretLength)
+	}
 	return cfg, nil
 }
@@ -0,0 +1,155 @@
+// Package migrator applies the embedded migrations with golang-migrate. Migrations are
+// recorded in its schema_migrations table, so it can be used alongside the migrate CLI,
+// and run under a postgres advisory lock so concurrent replicas wait for each other.
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5" // registers the pgx5 scheme
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/robertt3kuk/xiaoma-test-task/migration"
+)
+
+// Migrator -.
+type Migrator struct {
+	m   *migrate.Migrate
+	src source.Driver
+}
+
+// Migration is an embedded migration and whether it is applied
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// New opens the database at the postgres url with the embedded migrations
+func New(url string) (*Migrator, error) {
+	src, err := iofs.New(migration.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator - New - iofs.New: %w", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL(url))
+	if err != nil {
+		return nil, fmt.Errorf("migrator - New - migrate.NewWithSourceInstance: %w", err)
+	}
+	// the source instance is shared with m, which closes it
+	src, err = iofs.New(migration.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator - New - iofs.New: %w", err)
+	}
+	return &Migrator{m: m, src: src}, nil
+}
+
+// databaseURL switches a postgres url to the pgx v5 driver of golang-migrate
+func databaseURL(url string) string {
+	for _, scheme := range []string{"postgres://", "postgresql://"} {
+		if strings.HasPrefix(url, scheme) {
+			return "pgx5://" + strings.TrimPrefix(url, scheme)
+		}
+	}
+	return url
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	err := m.m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator - Up - m.m.Up: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the last steps migrations
+func (m *Migrator) Down(steps int) error {
+	err := m.m.Steps(-steps)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator - Down - m.m.Steps: %w", err)
+	}
+	return nil
+}
+
+// Version returns the applied version, 0 when nothing is applied, and whether the last migration failed halfway
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrator - Version - m.m.Version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Status lists the embedded migrations in order
+func (m *Migrator) Status() ([]Migration, error) {
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, fmt.Errorf("migrator - Status - m.Version: %w", err)
+	}
+	var migrations []Migration
+	version, err := m.src.First()
+	for err == nil {
+		name := ""
+		if r, identifier, err := m.src.ReadUp(version); err == nil {
+			r.Close()
+			name = identifier
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, Applied: version <= current})
+		version, err = m.src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("migrator - Status - m.src.Next: %w", err)
+	}
+	return migrations, nil
+}
+
+// Pending returns the number of embedded migrations that are not applied
+func (m *Migrator) Pending() (int, error) {
+	migrations, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, migration := range migrations {
+		if !migration.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Close -.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr, m.src.Close())
+}
+
+// Latest returns the version of the last embedded migration
+func Latest() (uint, error) {
+	src, err := iofs.New(migration.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("migrator - Latest - iofs.New: %w", err)
+	}
+	defer src.Close()
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("migrator - Latest - src.First: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("migrator - Latest - src.Next: %w", err)
+		}
+		version = next
+	}
+}
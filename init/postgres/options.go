@@ -1,6 +1,10 @@
 package postgres
 
-import "time"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
 
 // Option -.
 type Option func(*Postgres)
@@ -25,3 +29,10 @@ func ConnTimeout(timeout time.Duration) Option {
 		c.connTimeout = timeout
 	}
 }
+
+// QueryTracer is called around every query of the pool
+func QueryTracer(tracer pgx.QueryTracer) Option {
+	return func(c *Postgres) {
+		c.queryTracer = tracer
+	}
+}
@@ -0,0 +1,33 @@
+package tracer
+
+// Option -.
+type Option func(*Tracer)
+
+// ServiceName -.
+func ServiceName(name string) Option {
+	return func(t *Tracer) {
+		t.serviceName = name
+	}
+}
+
+// ServiceVersion -.
+func ServiceVersion(version string) Option {
+	return func(t *Tracer) {
+		t.serviceVersion = version
+	}
+}
+
+// Endpoint is the URL of the OTLP/HTTP collector, e.g. http://localhost:4318
+func Endpoint(url string) Option {
+	return func(t *Tracer) {
+		t.endpoint = url
+	}
+}
+
+// SampleRatio is the share of new traces that are recorded, requests that carry a sampled
+// parent are always recorded
+func SampleRatio(ratio float64) Option {
+	return func(t *Tracer) {
+		t.sampleRatio = ratio
+	}
+}
@@ -0,0 +1,103 @@
+// Package tracer installs the OpenTelemetry tracer provider and the W3C trace context propagator.
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+const (
+	_defaultServiceName = "xiaoma-test"
+	_defaultSampleRatio = 1
+)
+
+// Tracer -.
+type Tracer struct {
+	serviceName    string
+	serviceVersion string
+	endpoint       string
+	sampleRatio    float64
+
+	provider *sdktrace.TracerProvider
+}
+
+// New installs a tracer provider sending spans to exporter, with ExporterNone the spans are
+// dropped but the incoming trace context is still propagated
+func New(exporter string, opts ...Option) (*Tracer, error) {
+	t := &Tracer{
+		serviceName: _defaultServiceName,
+		sampleRatio: _defaultSampleRatio,
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return t, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		// without an endpoint the exporter reads the OTEL_EXPORTER_OTLP_* variables
+		var options []otlptracehttp.Option
+		if t.endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(t.endpoint))
+		}
+		spanExporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return nil, fmt.Errorf("tracer - New: unknown exporter %q, expected otlp, stdout or none", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracer - New - %s exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(t.serviceName),
+		semconv.ServiceVersion(t.serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracer - New - resource.Merge: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.sampleRatio))),
+	)
+	otel.SetTracerProvider(t.provider)
+
+	return t, nil
+}
+
+// Shutdown flushes the spans that are not exported yet
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
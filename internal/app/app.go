@@ -1,32 +1,71 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/robertt3kuk/xiaoma-test-task/config"
 	"github.com/robertt3kuk/xiaoma-test-task/init/httpserver"
 	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
 	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/init/tracer"
 	v1 "github.com/robertt3kuk/xiaoma-test-task/internal/delivery/http/v1"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/health"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
 )
 
 func Run(cfg *config.Config) {
 	l := logger.New(cfg.Log.Level)
-	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PoolMax))
+	if cfg.PG.AutoMigrate {
+		err := migrateUp(cfg.PG.URL)
+		if err != nil {
+			panic(err)
+		}
+		l.Info("app - Run - migrations applied")
+	}
+	t, err := tracer.New(
+		cfg.Trace.Exporter,
+		tracer.ServiceName(cfg.App.Name),
+		tracer.ServiceVersion(cfg.App.Version),
+		tracer.Endpoint(cfg.Trace.Endpoint),
+		tracer.SampleRatio(cfg.Trace.SampleRatio),
+	)
+	if err != nil {
+		panic(err)
+	}
+	pg, err := postgres.New(
+		cfg.PG.URL,
+		postgres.MaxPoolSize(cfg.PoolMax),
+		postgres.QueryTracer(tracing.NewQueryTracer()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(pg.Pool))
+
+	service := NewService(cfg, pg)
+
+	checker := health.New()
+	checker.Add("postgres", pg.Pool.Ping)
+	migrations, err := migrationsCheck(pg)
 	if err != nil {
 		panic(err)
 	}
+	checker.Add("migrations", migrations)
 
-	repo := service.NewRepo(pg)
-	service := service.New(repo)
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go purgeIdempotencyKeys(purgeCtx, l, service.Idempotency, cfg.Idempotency.PurgeInterval)
 
 	handler := fiber.New()
-	v1.NewRouter(handler, l, service)
+	v1.NewRouter(handler, l, service, checker, cfg.HTTP.ExportTimeout)
 
 	httpServer := httpserver.New(handler.Handler(), cfg.HTTP.Port)
 	// Waiting signal
@@ -36,6 +75,9 @@ func Run(cfg *config.Config) {
 	select {
 	case s := <-interrupt:
 		l.Info("app - Run - signal: " + s.String())
+		// readiness fails first so the load balancer drains the traffic before the server stops
+		checker.Drain()
+		time.Sleep(cfg.HTTP.DrainTimeout)
 	case err = <-httpServer.Notify():
 		l.Error(fmt.Errorf("app - Run - httpServer.Notify: %w", err))
 	}
@@ -45,4 +87,35 @@ func Run(cfg *config.Config) {
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %w", err))
 	}
+	err = t.Shutdown(context.Background())
+	if err != nil {
+		l.Error(fmt.Errorf("app - Run - t.Shutdown: %w", err))
+	}
+}
+
+// NewService wires the repositories and the services on pg
+func NewService(cfg *config.Config, pg *postgres.Postgres) *service.Service {
+	return service.New(service.NewRepo(pg), service.TokenConfig{
+		Secret: []byte(cfg.Auth.JWTSecret),
+		TTL:    cfg.Auth.TokenTTL,
+	}, cfg.Idempotency.TTL)
+}
+
+// purgeIdempotencyKeys deletes the expired idempotency keys every interval until ctx is done
+func purgeIdempotencyKeys(ctx context.Context, l logger.Interface, s service.Idempotency, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, status := s.Purge(ctx)
+			if !status.Ok() {
+				l.Error(fmt.Errorf("app - purgeIdempotencyKeys - s.Purge: %w", status.Err))
+				continue
+			}
+			l.Debug("app - purgeIdempotencyKeys - purged %d keys", purged)
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/robertt3kuk/xiaoma-test-task/config"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+// Import runs `import [-dry-run] [-upsert] [-tenant ID] items|customers FILE` and prints the result as JSON
+func Import(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	var options model.ImportOptions
+	flags.BoolVar(&options.DryRun, "dry-run", false, "check the file and roll the import back")
+	flags.BoolVar(&options.Upsert, "upsert", false, "update the rows whose name already exists")
+	tenantID := flags.Int("tenant", tenant.Default, "id of the tenant the rows are imported into")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import [-dry-run] [-upsert] [-tenant ID] items|customers FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("app - Import: expected the kind and the file")
+	}
+	kind, path := flags.Arg(0), flags.Arg(1)
+	if *tenantID <= 0 {
+		return fmt.Errorf("app - Import: unknown tenant %d, expected a positive id", *tenantID)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("app - Import - os.Open: %w", err)
+	}
+	defer file.Close()
+
+	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PoolMax))
+	if err != nil {
+		return fmt.Errorf("app - Import - postgres.New: %w", err)
+	}
+	defer pg.Close()
+	s := NewService(cfg, pg)
+
+	// rows imported from the command line are recorded as changed by the cli
+	ctx := service.WithActor(context.Background(), model.Actor{Name: "cli:import"})
+	ctx = tenant.WithID(ctx, *tenantID)
+	var result model.ImportResult
+	var status service.Status
+	switch kind {
+	case "items":
+		result, status = s.ImportItems(ctx, file, options)
+	case "customers":
+		result, status = s.ImportCustomers(ctx, file, options)
+	default:
+		return fmt.Errorf("app - Import: unknown kind %q, expected items or customers", kind)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("app - Import - encoder.Encode: %w", err)
+	}
+	if !status.Ok() {
+		return fmt.Errorf("app - Import: %s: %w", status.Msg, status.Err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/config"
+	"github.com/robertt3kuk/xiaoma-test-task/init/migrator"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/health"
+)
+
+// Migrate runs `migrate up|down [N]|status|version` with the embedded migrations
+func Migrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: migrate up|down [N]|status|version")
+		fmt.Fprintln(flags.Output(), "  down rolls back N migrations, 1 by default")
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("app - Migrate: expected a command")
+	}
+
+	m, err := migrator.New(cfg.PG.URL)
+	if err != nil {
+		return fmt.Errorf("app - Migrate - migrator.New: %w", err)
+	}
+	defer m.Close()
+
+	switch flags.Arg(0) {
+	case "up":
+		return m.Up()
+	case "down":
+		steps := 1
+		if flags.NArg() > 1 {
+			steps, err = strconv.Atoi(flags.Arg(1))
+			if err != nil || steps < 1 {
+				return fmt.Errorf("app - Migrate: down expects a positive number of steps, got %q", flags.Arg(1))
+			}
+		}
+		return m.Down(steps)
+	case "version":
+		version, dirty, err := m.Version()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+		return nil
+	case "status":
+		migrations, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, migration := range migrations {
+			fmt.Fprintf(w, "%d\t%s\t%t\n", migration.Version, migration.Name, migration.Applied)
+		}
+		return w.Flush()
+	}
+	flags.Usage()
+	return fmt.Errorf("app - Migrate: unknown command %q", flags.Arg(0))
+}
+
+// migrateUp applies the pending migrations, replicas starting together wait on the migration lock
+func migrateUp(url string) error {
+	m, err := migrator.New(url)
+	if err != nil {
+		return fmt.Errorf("app - migrateUp - migrator.New: %w", err)
+	}
+	defer m.Close()
+	err = m.Up()
+	if err != nil {
+		return fmt.Errorf("app - migrateUp - m.Up: %w", err)
+	}
+	return nil
+}
+
+// migrationsCheck fails until the last embedded migration is applied, it reads the version
+// table of golang-migrate through the pool so a probe doesn't open a connection of its own
+func migrationsCheck(pg *postgres.Postgres) (health.Check, error) {
+	latest, err := migrator.Latest()
+	if err != nil {
+		return nil, fmt.Errorf("app - migrationsCheck - migrator.Latest: %w", err)
+	}
+	return func(ctx context.Context) error {
+		var version int64
+		var dirty bool
+		err := pg.Pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("schema_migrations: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed halfway", version)
+		}
+		if uint(version) < latest {
+			return fmt.Errorf("migration %d is applied, %d is the latest", version, latest)
+		}
+		return nil
+	}, nil
+}
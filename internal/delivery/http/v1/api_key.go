@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type APIKeyRoutes struct {
+	l logger.Interface
+	s service.APIKey
+}
+
+func NewAPIKeyRoutes(l logger.Interface, s service.APIKey) *APIKeyRoutes {
+	return &APIKeyRoutes{l: l, s: s}
+}
+
+type APIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+func (r *APIKeyRequest) toModel() model.APIKey {
+	return model.APIKey{
+		Name:  r.Name,
+		Scope: r.Scope,
+	}
+}
+
+func (r *APIKeyRequest) validate() error {
+	var err string
+	if r.Name == "" {
+		err += " name is required,"
+	}
+	if !model.IsAPIKeyScope(r.Scope) {
+		err += " scope must be read_only, cashier or admin"
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	}
+	return nil
+}
+
+// Create returns the new key once, only its hash is kept
+func (r *APIKeyRoutes) Create(c fiber.Ctx) error {
+	var request APIKeyRequest
+	if err := c.Bind().JSON(&request); err != nil {
+		r.l.Error("APIKeyRoutes - Create - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err := request.validate()
+	if err != nil {
+		r.l.Error("APIKeyRoutes - Create - request.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.Create(c.UserContext(), request.toModel())
+	if !status.Ok() {
+		r.l.Error("APIKeyRoutes - Create - r.s.Create:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *APIKeyRoutes) GetAll(c fiber.Ctx) error {
+	result, status := r.s.GetAll(c.UserContext())
+	if !status.Ok() {
+		r.l.Error("APIKeyRoutes - GetAll - r.s.GetAll:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *APIKeyRoutes) Revoke(c fiber.Ctx) error {
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("APIKeyRoutes - Revoke - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	result, status := r.s.Revoke(c.UserContext(), idParamInt)
+	if !status.Ok() {
+		r.l.Error("APIKeyRoutes - Revoke - r.s.Revoke:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type AuditRoutes struct {
+	l logger.Interface
+	s service.Audit
+}
+
+func NewAuditRoutes(l logger.Interface, s service.Audit) *AuditRoutes {
+	return &AuditRoutes{l: l, s: s}
+}
+
+func (r *AuditRoutes) GetAll(c fiber.Ctx) error {
+	var filter model.AuditFilter
+	err := parseAuditFilter(c, &filter)
+	if err != nil {
+		r.l.Error("AuditRoutes - GetAll - parseAuditFilter:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.GetByFilter(c.UserContext(), filter)
+	if !status.Ok() {
+		r.l.Error("AuditRoutes - GetAll - r.s.GetByFilter:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+// parseAuditFilter reads the search from the query string:
+//
+//	actor, action, entity, entity_id, request_id
+//	created_from, created_to          RFC 3339 time or a YYYY-MM-DD date, a created_to date includes the whole day
+//	limit, offset
+func parseAuditFilter(c fiber.Ctx, filter *model.AuditFilter) error {
+	var errs []string
+	intParam := func(key string, dst *int) {
+		if v := c.Query(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, key+" is invalid integer")
+				return
+			}
+			*dst = n
+		}
+	}
+	timeParam := func(key string, dst **time.Time, endOfDay bool) {
+		t, err := timeQuery(c, key, endOfDay)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		*dst = t
+	}
+
+	filter.Actor = c.Query("actor")
+	filter.Action = c.Query("action")
+	filter.Entity = c.Query("entity")
+	intParam("entity_id", &filter.EntityID)
+	filter.RequestID = c.Query("request_id")
+	timeParam("created_from", &filter.CreatedFrom, false)
+	timeParam("created_to", &filter.CreatedTo, true)
+	intParam("limit", &filter.Limit)
+	intParam("offset", &filter.Offset)
+
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
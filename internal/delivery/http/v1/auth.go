@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	tenantHeader = "X-Tenant-ID"
+)
+
+// authenticate resolves the bearer token of a user or the X-API-Key header into the actor of
+// the request, requests without valid credentials are rejected before they reach a handler
+func authenticate(l logger.Interface, keys service.APIKey, users service.User) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		var actor model.Actor
+		var status service.Status
+		if token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+			actor, status = users.Authenticate(c.UserContext(), token)
+		} else if key := c.Get(apiKeyHeader); key != "" {
+			actor, status = keys.Authenticate(c.UserContext(), key)
+		} else {
+			l.Error("authenticate - c.Get:%w", errors.New("missing the credentials"))
+			return c.Status(http.StatusUnauthorized).JSON(gin.H{"error": "bearer token or api key is required"})
+		}
+		if !status.Ok() {
+			l.Error("authenticate - Authenticate:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		c.SetUserContext(service.WithActor(c.UserContext(), actor))
+		return c.Next()
+	}
+}
+
+// authorize lets the request through when its actor has the permission
+func authorize(permission model.Permission) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !service.ActorFromContext(c.UserContext()).Can(permission) {
+			return c.Status(http.StatusForbidden).JSON(gin.H{"error": "missing permission " + string(permission)})
+		}
+		return c.Next()
+	}
+}
+
+// resolveTenant scopes the request to the tenant of its actor. Users and tenant keys are bound to
+// their tenant, a X-Tenant-ID naming another one is refused. Platform keys have no tenant and
+// must pick one with the header.
+func resolveTenant(l logger.Interface, tenants service.Tenant) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		actor := service.ActorFromContext(c.UserContext())
+		id := actor.TenantID
+		if header := c.Get(tenantHeader); header != "" {
+			requested, err := strconv.Atoi(header)
+			if err != nil || requested <= 0 {
+				l.Error("resolveTenant - strconv.Atoi:%w", err)
+				return c.Status(http.StatusBadRequest).JSON(gin.H{"error": tenantHeader + " must be a positive integer"})
+			}
+			if id != 0 && requested != id {
+				return c.Status(http.StatusForbidden).JSON(gin.H{"error": "credentials belong to another tenant"})
+			}
+			if id == 0 {
+				_, status := tenants.GetByID(c.UserContext(), requested)
+				if !status.Ok() {
+					l.Error("resolveTenant - tenants.GetByID:%w", status.Err)
+					return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+				}
+			}
+			id = requested
+		}
+		if id == 0 {
+			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": tenantHeader + " is required for a platform api key"})
+		}
+		c.SetUserContext(tenant.WithID(c.UserContext(), id))
+		return c.Next()
+	}
+}
@@ -21,8 +21,8 @@ func NewCustomerRoutes(l logger.Interface, s service.Customer) *CustomerRoutes {
 }
 
 type CustomerRequest struct {
-	Name    string  `json:"customer_name"`
-	Balance float64 `json:"balance"`
+	Name    string      `json:"customer_name"`
+	Balance model.Money `json:"balance"`
 }
 
 func (c *CustomerRequest) toModel() model.Customer {
@@ -33,12 +33,29 @@ func (c *CustomerRequest) toModel() model.Customer {
 }
 
 func (c *CustomerRequest) validate() error {
+	return c.toModel().Validate()
+}
+
+type BalanceOperationRequest struct {
+	Amount model.Money `json:"amount"`
+	Reason string      `json:"reason"`
+}
+
+func (b *BalanceOperationRequest) toModel(customerID int) model.BalanceOperation {
+	return model.BalanceOperation{
+		CustomerID: customerID,
+		Amount:     b.Amount,
+		Reason:     b.Reason,
+	}
+}
+
+func (b *BalanceOperationRequest) validate() error {
 	var err string
-	if c.Name == "" || len(c.Name) < 3 {
-		err += " name is invalid or shorter than 3,"
+	if b.Amount <= 0 {
+		err += " amount is invalid or equal or less than zero,"
 	}
-	if c.Balance <= 0 {
-		err += " balance is invalid or equal or less than zero"
+	if b.Reason == "" {
+		err += " reason is required"
 	}
 	if len(err) > 0 {
 		return errors.New(err)
@@ -60,7 +77,7 @@ func (r *CustomerRoutes) Create(c fiber.Ctx) error {
 		return c.Status(400).JSON(gin.H{"error": err.Error()})
 	}
 	customer := requestBody.toModel()
-	result, status := r.s.Create(c.Context(), customer)
+	result, status := r.s.Create(c.UserContext(), customer)
 	if !status.Ok() {
 		r.l.Error("CustomerRoutes - Create - r.s.Create:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -90,7 +107,7 @@ func (r *CustomerRoutes) Update(c fiber.Ctx) error {
 	}
 	customerBody := customer.toModel()
 	customerBody.ID = idParamInt
-	result, status := r.s.Update(c.Context(), customerBody)
+	result, status := r.s.Update(c.UserContext(), customerBody)
 	if !status.Ok() {
 		r.l.Error("CustomerRoutes - Update - r.s.Update:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -112,7 +129,7 @@ func (r *CustomerRoutes) GetByID(c fiber.Ctx) error {
 		r.l.Error("CustomerRoutes - GetByID - parseInt:%w", err)
 		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	result, status := r.s.GetByID(c.Context(), idParamInt)
+	result, status := r.s.GetByID(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error("CustomerRoutes - GetByID - r.s.GetByID:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -122,7 +139,7 @@ func (r *CustomerRoutes) GetByID(c fiber.Ctx) error {
 
 func (r *CustomerRoutes) GetAll(c fiber.Ctx) error {
 	limitF := c.FormValue("limit")
-	offsetF := c.FormValue("offest")
+	offsetF := c.FormValue("offset")
 	limit, err := strconv.Atoi(limitF)
 
 	if limitF != "" {
@@ -131,6 +148,14 @@ func (r *CustomerRoutes) GetAll(c fiber.Ctx) error {
 			return c.Status(400).JSON(gin.H{"error": "limit is invalid integer"})
 		}
 	}
+	if cursor, ok := cursorQuery(c); ok {
+		result, status := r.s.GetPage(c.UserContext(), cursor, limit)
+		if !status.Ok() {
+			r.l.Error("CustomerRoutes - GetAll - r.s.GetPage:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		return c.Status(status.Code).JSON(result)
+	}
 	offset, err := strconv.Atoi(offsetF)
 	if offsetF != "" {
 		if err != nil {
@@ -139,7 +164,7 @@ func (r *CustomerRoutes) GetAll(c fiber.Ctx) error {
 		}
 	}
 
-	result, status := r.s.GetAll(c.Context(), limit, offset)
+	result, status := r.s.GetAll(c.UserContext(), limit, offset)
 	if !status.Ok() {
 		r.l.Error("CustomerRoutes - GetAll - r.s.GetAll:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -161,10 +186,60 @@ func (r *CustomerRoutes) Delete(c fiber.Ctx) error {
 		r.l.Error("CustomerRoutes - Delete - parseInt:%w", err)
 		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	status := r.s.Delete(c.Context(), idParamInt)
+	status := r.s.Delete(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error("CustomerRoutes - Delete - r.s.Delete:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
 	}
 	return c.Status(status.Code).Send([]byte(status.Msg))
 }
+
+func (r *CustomerRoutes) Deposit(c fiber.Ctx) error {
+	var requestBody BalanceOperationRequest
+	err := c.Bind().JSON(&requestBody)
+	if err != nil {
+		r.l.Error("CustomerRoutes - Deposit - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err = requestBody.validate()
+	if err != nil {
+		r.l.Error("CustomerRoutes - Deposit - requestBody.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("CustomerRoutes - Deposit - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	balance, status := r.s.Deposit(c.UserContext(), requestBody.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("CustomerRoutes - Deposit - r.s.Deposit:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(gin.H{"customer_id": idParamInt, "balance": balance})
+}
+
+func (r *CustomerRoutes) Withdraw(c fiber.Ctx) error {
+	var requestBody BalanceOperationRequest
+	err := c.Bind().JSON(&requestBody)
+	if err != nil {
+		r.l.Error("CustomerRoutes - Withdraw - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err = requestBody.validate()
+	if err != nil {
+		r.l.Error("CustomerRoutes - Withdraw - requestBody.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("CustomerRoutes - Withdraw - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	balance, status := r.s.Withdraw(c.UserContext(), requestBody.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("CustomerRoutes - Withdraw - r.s.Withdraw:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(gin.H{"customer_id": idParamInt, "balance": balance})
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	_ "time/tzdata" // the tz parameter must work on hosts without a zoneinfo database
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/export"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+// exportFormat picks the format from the format parameter, then from the Accept header, CSV by default
+func exportFormat(c fiber.Ctx) (string, error) {
+	if format := c.Query("format"); format != "" {
+		if _, ok := export.ContentTypes[format]; !ok {
+			return "", fmt.Errorf("format must be %s or %s", export.FormatCSV, export.FormatXLSX)
+		}
+		return format, nil
+	}
+	switch c.Accepts(export.ContentTypes[export.FormatCSV], export.ContentTypes[export.FormatXLSX]) {
+	case export.ContentTypes[export.FormatXLSX]:
+		return export.FormatXLSX, nil
+	}
+	return export.FormatCSV, nil
+}
+
+// ExportTransactionViews streams the transaction search as a file, it takes the search parameters of
+// GetAllTransactionViewByFilters and:
+//
+//	format     csv or xlsx, the Accept header is used when it is missing
+//	columns    comma separated columns, all of export.TransactionViewColumns by default
+//	tz         IANA time zone of the timestamps, UTC by default
+//
+// Unlike the search, a missing limit exports every matching row, the file is cut short after HTTP_EXPORT_TIMEOUT.
+func (r *TransactionRoutes) ExportTransactionViews(c fiber.Ctx) error {
+	filter := model.TransactionFilter{}
+	err := parseTransactionFilter(c, &filter)
+	if err != nil {
+		r.l.Error("TransactionRoutes - ExportTransactionViews - parseTransactionFilter:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	format, err := exportFormat(c)
+	if err != nil {
+		r.l.Error("TransactionRoutes - ExportTransactionViews - exportFormat:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	columns := export.TransactionViewColumns
+	if v := c.Query("columns"); v != "" {
+		columns = strings.Split(v, ",")
+		for _, column := range columns {
+			if !export.IsTransactionViewColumn(column) {
+				return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "unknown column " + column})
+			}
+		}
+	}
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		r.l.Error("TransactionRoutes - ExportTransactionViews - time.LoadLocation:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "tz is invalid time zone"})
+	}
+	stream, status := r.s.ExportTransactionViews(&filter)
+	if !status.Ok() {
+		r.l.Error("TransactionRoutes - ExportTransactionViews - r.s.ExportTransactionViews:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentTypes[format])
+	c.Set(
+		fiber.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="transactions-%s.%s"`, time.Now().In(loc).Format("20060102-150405"), format),
+	)
+	// the body is written after the handler returns, so the writer must not touch c,
+	// the status is already sent by then and a failure can only cut the file short.
+	// The query stops when the export times out or a write to the client fails.
+	ctx, cancel := context.WithTimeout(tenant.WithID(context.Background(), tenant.ID(c.UserContext())), r.exportTimeout)
+	c.Response().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer w.Flush()
+		err := export.WriteTransactionViews(ctx, w, stream, format, columns, loc)
+		if err != nil {
+			r.l.Error("TransactionRoutes - ExportTransactionViews - export.WriteTransactionViews:%w", err)
+		}
+	})
+	// Send would drop the stream writer
+	c.Status(status.Code)
+	return nil
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/health"
+)
+
+type HealthRoutes struct {
+	l logger.Interface
+	h *health.Checker
+}
+
+func NewHealthRoutes(l logger.Interface, h *health.Checker) *HealthRoutes {
+	return &HealthRoutes{l: l, h: h}
+}
+
+// Live answers as long as the process serves requests, it doesn't look at the dependencies
+// so a database outage doesn't get the pod restarted
+func (r *HealthRoutes) Live(c fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(gin.H{"status": health.StatusUp})
+}
+
+// Ready reports every component and answers 503 while one is down or the server is draining
+func (r *HealthRoutes) Ready(c fiber.Ctx) error {
+	report := r.h.Ready(c.UserContext())
+	if !report.Ok() {
+		r.l.Warn("HealthRoutes - Ready - status: " + report.Status)
+		return c.Status(http.StatusServiceUnavailable).JSON(report)
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks a response that was stored by an earlier request with the same key
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotent makes a mutating request with an Idempotency-Key header run once per key and actor,
+// retries get the stored response. A request that fails with an error or a 5xx releases its key
+// so it can be retried, any other response is kept until the key expires.
+func idempotent(l logger.Interface, s service.Idempotency) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key := c.Get(idempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		record, status := s.Begin(c.UserContext(), key, c.Method(), c.OriginalURL(), c.Body())
+		if !status.Ok() {
+			l.Error("idempotent - s.Begin:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		if record.StatusCode != nil {
+			c.Set(idempotentReplayedHeader, "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(*record.StatusCode).Send(record.ResponseBody)
+		}
+
+		err := c.Next()
+		code := c.Response().StatusCode()
+		if err != nil || code >= http.StatusInternalServerError {
+			if status := s.Release(c.UserContext(), record); !status.Ok() {
+				l.Error("idempotent - s.Release:%w", status.Err)
+			}
+			return err
+		}
+		record.StatusCode = &code
+		record.ContentType = string(c.Response().Header.ContentType())
+		record.ResponseBody = bytes.Clone(c.Response().Body())
+		if status := s.Complete(c.UserContext(), record); !status.Ok() {
+			// the request is done, a retry will be rejected as in progress until the key expires
+			l.Error("idempotent - s.Complete:%w", status.Err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type ImportRoutes struct {
+	l logger.Interface
+	s service.Import
+}
+
+func NewImportRoutes(l logger.Interface, s service.Import) *ImportRoutes {
+	return &ImportRoutes{l: l, s: s}
+}
+
+// importRequest returns the CSV file, sent either as the request body or as the multipart field file,
+// and the dry_run and upsert options of the query string
+func importRequest(c fiber.Ctx) (io.ReadCloser, model.ImportOptions, error) {
+	var options model.ImportOptions
+	var err error
+	if v := c.Query("dry_run"); v != "" {
+		if options.DryRun, err = strconv.ParseBool(v); err != nil {
+			return nil, options, errors.New("dry_run is invalid boolean")
+		}
+	}
+	if v := c.Query("upsert"); v != "" {
+		if options.Upsert, err = strconv.ParseBool(v); err != nil {
+			return nil, options, errors.New("upsert is invalid boolean")
+		}
+	}
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return nil, options, errors.New("couldn't open file")
+		}
+		return file, options, nil
+	}
+	if len(c.Body()) == 0 {
+		return nil, options, errors.New("file is required")
+	}
+	return io.NopCloser(bytes.NewReader(c.Body())), options, nil
+}
+
+func (r *ImportRoutes) Items(c fiber.Ctx) error {
+	file, options, err := importRequest(c)
+	if err != nil {
+		r.l.Error("ImportRoutes - Items - importRequest:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	defer file.Close()
+	result, status := r.s.ImportItems(c.UserContext(), file, options)
+	return r.respond(c, "ImportRoutes - Items - r.s.ImportItems:%w", result, status)
+}
+
+func (r *ImportRoutes) Customers(c fiber.Ctx) error {
+	file, options, err := importRequest(c)
+	if err != nil {
+		r.l.Error("ImportRoutes - Customers - importRequest:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	defer file.Close()
+	result, status := r.s.ImportCustomers(c.UserContext(), file, options)
+	return r.respond(c, "ImportRoutes - Customers - r.s.ImportCustomers:%w", result, status)
+}
+
+// respond writes the import result, rejected rows are listed in the result itself
+func (r *ImportRoutes) respond(c fiber.Ctx, caller string, result model.ImportResult, status service.Status) error {
+	if !status.Ok() {
+		r.l.Error(caller, status.Err)
+		if status.Code == http.StatusUnprocessableEntity {
+			return c.Status(status.Code).JSON(result)
+		}
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
@@ -2,7 +2,6 @@ package v1
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -23,10 +22,10 @@ func NewItemRoutes(l logger.Interface, s service.Item) *ItemRoutes {
 
 //swagger:model
 type ItemRequest struct {
-	ItemName string  `json:"item_name" required:"true"`
-	Cost     float64 `json:"cost"      required:"true"`
-	Price    float64 `json:"price"     required:"true"`
-	Sort     int     `json:"sort"      required:"true"`
+	ItemName string      `json:"item_name" required:"true"`
+	Cost     model.Money `json:"cost"      required:"true"`
+	Price    model.Money `json:"price"     required:"true"`
+	Sort     int         `json:"sort"      required:"true"`
 }
 
 func (i *ItemRequest) toModel() model.Item {
@@ -40,24 +39,7 @@ func (i *ItemRequest) toModel() model.Item {
 }
 
 func (i *ItemRequest) validate() error {
-	var err string
-	if i.ItemName == "" || len(i.ItemName) < 3 {
-		err += " item name is invalid or shorter than 3,"
-	}
-	if i.Cost <= 0 {
-		err += " cost is invalid or under zero,"
-	}
-	if i.Price <= 0 {
-		err += " price is invalid or under zero,"
-	}
-	if i.Sort <= 0 {
-		err += " sort is invalid or under zero,"
-	}
-	if len(err) != 0 {
-		return errors.New(err)
-	} else {
-		return nil
-	}
+	return i.toModel().Validate()
 }
 
 func (r *ItemRoutes) Create(c fiber.Ctx) error {
@@ -72,7 +54,7 @@ func (r *ItemRoutes) Create(c fiber.Ctx) error {
 		return c.Status(400).JSON(gin.H{"error": err.Error()})
 	}
 	itemb := item.toModel()
-	result, status := r.s.Create(c.Context(), itemb)
+	result, status := r.s.Create(c.UserContext(), itemb)
 	if !status.Ok() {
 		r.l.Error("ItemRoutes - Create - r.s.Create:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -98,7 +80,7 @@ func (r *ItemRoutes) Update(c fiber.Ctx) error {
 	}
 	itemb := item.toModel()
 	itemb.ID = idParamInt
-	result, status := r.s.Update(c.Context(), itemb)
+	result, status := r.s.Update(c.UserContext(), itemb)
 	if !status.Ok() {
 		r.l.Error("ItemRoutes - Update - r.s.Update:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -117,7 +99,7 @@ func (r *ItemRoutes) GetByID(c fiber.Ctx) error {
 		r.l.Error("ItemRoutes - GetByID - parseInt:%w", err)
 		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	result, status := r.s.GetByID(c.Context(), idParamInt)
+	result, status := r.s.GetByID(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error("ItemRoutes - GetByID - r.s.GetByID:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -127,8 +109,7 @@ func (r *ItemRoutes) GetByID(c fiber.Ctx) error {
 
 func (r *ItemRoutes) GetAll(c fiber.Ctx) error {
 	limitF := c.FormValue("limit")
-	offsetF := c.FormValue("offest")
-	fmt.Println(limitF, offsetF)
+	offsetF := c.FormValue("offset")
 	limit, err := strconv.Atoi(limitF)
 	if limitF != "" {
 		if err != nil {
@@ -136,6 +117,14 @@ func (r *ItemRoutes) GetAll(c fiber.Ctx) error {
 			return c.Status(400).JSON(gin.H{"error": "limit is invalid integer"})
 		}
 	}
+	if cursor, ok := cursorQuery(c); ok {
+		result, status := r.s.GetPage(c.UserContext(), cursor, limit)
+		if !status.Ok() {
+			r.l.Error("ItemRoutes - GetAll - r.s.GetPage:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		return c.Status(status.Code).JSON(result)
+	}
 	offset, err := strconv.Atoi(offsetF)
 	if offsetF != "" {
 		if err != nil {
@@ -143,7 +132,7 @@ func (r *ItemRoutes) GetAll(c fiber.Ctx) error {
 			return c.Status(400).JSON(gin.H{"error": "offset is invalid integer"})
 		}
 	}
-	result, status := r.s.GetAll(c.Context(), limit, offset)
+	result, status := r.s.GetAll(c.UserContext(), limit, offset)
 	if !status.Ok() {
 		r.l.Error("ItemRoutes - GetAll - r.s.GetAll:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -162,7 +151,7 @@ func (r *ItemRoutes) Delete(c fiber.Ctx) error {
 		r.l.Error("ItemRoutes - Delete - parseInt:%w", err)
 		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	status := r.s.Delete(c.Context(), idParamInt)
+	status := r.s.Delete(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error("ItemRoutes - Delete - r.s.Delete:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type LedgerRoutes struct {
+	l logger.Interface
+	s service.Ledger
+}
+
+func NewLedgerRoutes(l logger.Interface, s service.Ledger) *LedgerRoutes {
+	return &LedgerRoutes{l: l, s: s}
+}
+
+func (r *LedgerRoutes) GetByCustomerID(c fiber.Ctx) error {
+	idParam := c.Params("id")
+	if idParam == "" {
+		r.l.Error(
+			"LedgerRoutes - GetByCustomerID - c.Params.Get:%w",
+			errors.New("missing the id parameter"),
+		)
+		return c.Status(400).JSON(gin.H{"error": "invalid request missing id in query parameters"})
+	}
+	idParamInt, err := strconv.Atoi(idParam)
+	if err != nil {
+		r.l.Error("LedgerRoutes - GetByCustomerID - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	limitF := c.FormValue("limit")
+	offsetF := c.FormValue("offset")
+	limit := 0
+	offset := 0
+	if limitF != "" {
+		limit, err = strconv.Atoi(limitF)
+		if err != nil {
+			r.l.Error("LedgerRoutes - GetByCustomerID - strconv.Atoi:%w", err)
+			return c.Status(400).JSON(gin.H{"error": "limit is invalid integer"})
+		}
+	}
+	if offsetF != "" {
+		offset, err = strconv.Atoi(offsetF)
+		if err != nil {
+			r.l.Error("LedgerRoutes - GetByCustomerID - strconv.Atoi:%w", err)
+			return c.Status(400).JSON(gin.H{"error": "offset is invalid integer"})
+		}
+	}
+	result, status := r.s.GetByCustomerID(c.UserContext(), idParamInt, limit, offset)
+	if !status.Ok() {
+		r.l.Error("LedgerRoutes - GetByCustomerID - r.s.GetByCustomerID:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *LedgerRoutes) Reconcile(c fiber.Ctx) error {
+	idParam := c.Params("id")
+	if idParam == "" {
+		r.l.Error(
+			"LedgerRoutes - Reconcile - c.Params.Get:%w",
+			errors.New("missing the id parameter"),
+		)
+		return c.Status(400).JSON(gin.H{"error": "invalid request missing id in query parameters"})
+	}
+	idParamInt, err := strconv.Atoi(idParam)
+	if err != nil {
+		r.l.Error("LedgerRoutes - Reconcile - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	result, status := r.s.Reconcile(c.UserContext(), idParamInt)
+	if !status.Ok() {
+		r.l.Error("LedgerRoutes - Reconcile - r.s.Reconcile:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type OrderRoutes struct {
+	l logger.Interface
+	s service.Order
+}
+
+func NewOrderRoutes(l logger.Interface, s service.Order) *OrderRoutes {
+	return &OrderRoutes{l: l, s: s}
+}
+
+type OrderRequest struct {
+	CustomerID int                `json:"customer_id"`
+	Lines      []OrderLineRequest `json:"lines"`
+}
+
+type OrderLineRequest struct {
+	ItemID int `json:"item_id"`
+	Qty    int `json:"qty"`
+}
+
+func (o *OrderRequest) toModel() model.Order {
+	order := model.Order{
+		CustomerID: o.CustomerID,
+		Lines:      make([]model.OrderLine, 0, len(o.Lines)),
+	}
+	for _, line := range o.Lines {
+		order.Lines = append(order.Lines, model.OrderLine{
+			ItemID: line.ItemID,
+			Qty:    line.Qty,
+		})
+	}
+	return order
+}
+
+func (o *OrderRequest) validate() error {
+	var err string
+	if o.CustomerID < 1 {
+		err += " customer id is invalid,"
+	}
+	if len(o.Lines) == 0 {
+		err += " order has no lines,"
+	}
+	for i, line := range o.Lines {
+		if line.ItemID < 1 {
+			err += fmt.Sprintf(" line %d item id is invalid,", i+1)
+		}
+		if line.Qty < 1 {
+			err += fmt.Sprintf(" line %d qty is invalid,", i+1)
+		}
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	} else {
+		return nil
+	}
+}
+
+func (r *OrderRoutes) Create(c fiber.Ctx) error {
+	var order OrderRequest
+	if err := c.Bind().JSON(&order); err != nil {
+		r.l.Error("OrderRoutes - Create - c.Bind.JSON:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
+	}
+	if err := order.validate(); err != nil {
+		r.l.Error("OrderRoutes - Create - order.validate:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	id, status := r.s.Create(c.UserContext(), order.toModel())
+	if !status.Ok() {
+		r.l.Error("OrderRoutes - Create - r.s.Create:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(gin.H{"id": id})
+}
+
+func (r *OrderRoutes) GetByID(c fiber.Ctx) error {
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("OrderRoutes - GetByID - parseInt:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	result, status := r.s.GetByID(c.UserContext(), idParamInt)
+	if !status.Ok() {
+		r.l.Error("OrderRoutes - GetByID - r.s.GetByID:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *OrderRoutes) GetAll(c fiber.Ctx) error {
+	limitF := c.FormValue("limit")
+	offsetF := c.FormValue("offset")
+	limit := 0
+	offset := 0
+	var err error
+	if limitF != "" {
+		limit, err = strconv.Atoi(limitF)
+		if err != nil {
+			r.l.Error("OrderRoutes - GetAll - strconv.Atoi:%w", err)
+			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "limit is invalid integer"})
+		}
+	}
+	if offsetF != "" {
+		offset, err = strconv.Atoi(offsetF)
+		if err != nil {
+			r.l.Error("OrderRoutes - GetAll - strconv.Atoi:%w", err)
+			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "offset is invalid integer"})
+		}
+	}
+	result, status := r.s.GetAll(c.UserContext(), limit, offset)
+	if !status.Ok() {
+		r.l.Error("OrderRoutes - GetAll - r.s.GetAll:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// cursorQuery reports whether the request asks for cursor pagination and returns its cursor,
+// an empty cursor= starts at the first page
+func cursorQuery(c fiber.Ctx) (string, bool) {
+	args := c.Request().URI().QueryArgs()
+	if !args.Has("cursor") {
+		return "", false
+	}
+	return string(args.Peek("cursor")), true
+}
+
+// timeQuery parses the key query parameter as an RFC 3339 time or a YYYY-MM-DD date,
+// with endOfDay a date stands for its last instant so that it includes the whole day
+func timeQuery(c fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, errors.New(key + " is invalid time")
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type ReportRoutes struct {
+	l logger.Interface
+	s service.Report
+}
+
+func NewReportRoutes(l logger.Interface, s service.Report) *ReportRoutes {
+	return &ReportRoutes{l: l, s: s}
+}
+
+// reportFilter reads from, to and group_by from the query string, a to date includes the whole day
+func reportFilter(c fiber.Ctx) (model.ReportFilter, error) {
+	from, fromErr := timeQuery(c, "from", false)
+	to, toErr := timeQuery(c, "to", true)
+	if err := errors.Join(fromErr, toErr); err != nil {
+		return model.ReportFilter{}, err
+	}
+	return model.ReportFilter{From: from, To: to, GroupBy: c.Query("group_by")}, nil
+}
+
+func (r *ReportRoutes) Sales(c fiber.Ctx) error {
+	filter, err := reportFilter(c)
+	if err != nil {
+		r.l.Error("ReportRoutes - Sales - reportFilter:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.Sales(c.UserContext(), filter)
+	if !status.Ok() {
+		r.l.Error("ReportRoutes - Sales - r.s.Sales:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *ReportRoutes) Items(c fiber.Ctx) error {
+	filter, err := reportFilter(c)
+	if err != nil {
+		r.l.Error("ReportRoutes - Items - reportFilter:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.Items(c.UserContext(), filter)
+	if !status.Ok() {
+		r.l.Error("ReportRoutes - Items - r.s.Items:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *ReportRoutes) Customers(c fiber.Ctx) error {
+	filter, err := reportFilter(c)
+	if err != nil {
+		r.l.Error("ReportRoutes - Customers - reportFilter:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.Customers(c.UserContext(), filter)
+	if !status.Ok() {
+		r.l.Error("ReportRoutes - Customers - r.s.Customers:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
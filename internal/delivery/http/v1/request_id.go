@@ -0,0 +1,16 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+// withRequestID puts the id set by the requestid middleware into c.UserContext,
+// the services write it to the audit log
+func withRequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.SetUserContext(service.WithRequestID(c.UserContext(), requestid.FromContext(c)))
+		return c.Next()
+	}
+}
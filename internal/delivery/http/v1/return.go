@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type ReturnRoutes struct {
+	l logger.Interface
+	s service.Return
+}
+
+func NewReturnRoutes(l logger.Interface, s service.Return) *ReturnRoutes {
+	return &ReturnRoutes{l: l, s: s}
+}
+
+type ReturnRequest struct {
+	Qty    int    `json:"qty"`
+	Reason string `json:"reason"`
+}
+
+func (r *ReturnRequest) toModel(transactionID int) model.TransactionReturn {
+	return model.TransactionReturn{
+		TransactionID: transactionID,
+		Qty:           r.Qty,
+		Reason:        r.Reason,
+	}
+}
+
+func (r *ReturnRequest) validate() error {
+	var err string
+	if r.Qty < 1 {
+		err += " qty is invalid or less than one,"
+	}
+	if r.Reason == "" {
+		err += " reason is required"
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	} else {
+		return nil
+	}
+}
+
+func (r *ReturnRoutes) Create(c fiber.Ctx) error {
+	var request ReturnRequest
+	if err := c.Bind().JSON(&request); err != nil {
+		r.l.Error("ReturnRoutes - Create - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err := request.validate()
+	if err != nil {
+		r.l.Error("ReturnRoutes - Create - request.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("ReturnRoutes - Create - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	result, status := r.s.Create(c.UserContext(), request.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("ReturnRoutes - Create - r.s.Create:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *ReturnRoutes) GetByTransactionID(c fiber.Ctx) error {
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("ReturnRoutes - GetByTransactionID - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	result, status := r.s.GetByTransactionID(c.UserContext(), idParamInt)
+	if !status.Ok() {
+		r.l.Error("ReturnRoutes - GetByTransactionID - r.s.GetByTransactionID:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
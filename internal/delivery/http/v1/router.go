@@ -1,65 +1,150 @@
 package v1
 
 import (
-	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
 
 	log "github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/health"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
 )
 
-func NewRouter(handler *fiber.App, l logger.Interface, t *service.Service) {
+func NewRouter(
+	handler *fiber.App,
+	l logger.Interface,
+	t *service.Service,
+	checker *health.Checker,
+	exportTimeout time.Duration,
+) {
 	conf := cors.Config{
 		AllowOrigins:     "*", // Equivalent to AllowAllOrigins: true
 		AllowMethods:     "POST, PUT, GET, DELETE, FETCH",
-		AllowHeaders:     "Origin, Content-type, X-API-Key",
+		AllowHeaders:     "Origin, Content-type, X-API-Key, Authorization, X-Request-ID, Idempotency-Key, X-Tenant-ID",
 		AllowCredentials: false,
-		ExposeHeaders:    "Content-Length",
+		ExposeHeaders:    "Content-Length, X-Request-ID, Idempotent-Replayed",
 		MaxAge:           3600,
 	}
 	handler.Use(cors.New(conf))
 	// handler.Use(recover.New())
+	// the X-Request-ID of the client is kept, otherwise a new one is generated and returned
+	handler.Use(requestid.New())
 	handler.Use(log.New(log.Config{
 		// For more options, see the Config section
-		Format: "${pid} ${locals:requestid} ${status} - ${method} ${path}​\n",
+		Format: "${pid} ${respHeader:X-Request-ID} ${status} - ${method} ${path}​\n",
 	}))
+	handler.Use(tracing.NewHTTPMiddleware())
+	handler.Use(withRequestID())
+	handler.Use(metrics.NewHTTPMiddleware())
+	handler.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
 
 	h := handler.Group("/v1")
 	itemRoutes := NewItemRoutes(l, t.Item)
 	customerRoutes := NewCustomerRoutes(l, t.Customer)
-	transactionRoutes := NewTransactionRoutes(l, t.Transaction)
-	h.Get(
-		"/healthz",
-		func(c fiber.Ctx) error { return c.Status(http.StatusOK).SendString("up and running") },
-	)
+	transactionRoutes := NewTransactionRoutes(l, t.Transaction, exportTimeout)
+	ledgerRoutes := NewLedgerRoutes(l, t.Ledger)
+	stockRoutes := NewStockRoutes(l, t.Stock)
+	orderRoutes := NewOrderRoutes(l, t.Order)
+	returnRoutes := NewReturnRoutes(l, t.Return)
+	reportRoutes := NewReportRoutes(l, t.Report)
+	importRoutes := NewImportRoutes(l, t.Import)
+	healthRoutes := NewHealthRoutes(l, checker)
+	apiKeyRoutes := NewAPIKeyRoutes(l, t.APIKey)
+	userRoutes := NewUserRoutes(l, t.User)
+	auditRoutes := NewAuditRoutes(l, t.Audit)
+	// /healthz is kept for the existing probes and behaves like /livez
+	h.Get("/healthz", healthRoutes.Live)
+	h.Get("/livez", healthRoutes.Live)
+	h.Get("/readyz", healthRoutes.Ready)
+	h.Post("/auth/login", userRoutes.Login)
+
+	// every route below needs a bearer token or an api key, the probes and the login stay public
+	h.Use(authenticate(l, t.APIKey, t.User))
+	// the repositories only see the rows of the tenant resolved from the credentials
+	h.Use(resolveTenant(l, t.Tenant))
+	// keys are scoped to the tenant and the actor, so they are checked after both
+	h.Use(idempotent(l, t.Idempotency))
+	h.Get("/auth/me", userRoutes.Me)
+
+	read, write := authorize(model.PermissionItemRead), authorize(model.PermissionItemWrite)
 	items := h.Group("/item")
-	items.Post("", itemRoutes.Create)
-	items.Put("/:id", itemRoutes.Update)
-	items.Get("/:id", itemRoutes.GetByID)
-	items.Get("", itemRoutes.GetAll)
-	items.Delete("/:id", itemRoutes.Delete)
+	items.Post("", itemRoutes.Create, write)
+	items.Post("/import", importRoutes.Items, write)
+	items.Put("/:id", itemRoutes.Update, write)
+	items.Get("/:id", itemRoutes.GetByID, read)
+	items.Get("", itemRoutes.GetAll, read)
+	items.Delete("/:id", itemRoutes.Delete, write)
+	items.Get("/:id/stock", stockRoutes.GetMovements, read)
+	items.Post("/:id/stock/receipt", stockRoutes.Receive, write)
+	items.Post("/:id/stock/adjustment", stockRoutes.Adjust, write)
 
+	read, write = authorize(model.PermissionCustomerRead), authorize(model.PermissionCustomerWrite)
+	balance := authorize(model.PermissionCustomerBalance)
 	customers := h.Group("/customer")
-	customers.Post("", customerRoutes.Create)
-	customers.Put("/:id", customerRoutes.Update)
-	customers.Get("/:id", customerRoutes.GetByID)
-	customers.Get("", customerRoutes.GetAll)
-	customers.Delete("/:id", customerRoutes.Delete)
+	customers.Post("", customerRoutes.Create, write)
+	customers.Post("/import", importRoutes.Customers, write)
+	customers.Put("/:id", customerRoutes.Update, write)
+	customers.Get("/:id", customerRoutes.GetByID, read)
+	customers.Get("", customerRoutes.GetAll, read)
+	customers.Delete("/:id", customerRoutes.Delete, write)
+	customers.Post("/:id/deposit", customerRoutes.Deposit, balance)
+	customers.Post("/:id/withdraw", customerRoutes.Withdraw, balance)
+	customers.Get("/:id/ledger", ledgerRoutes.GetByCustomerID, read)
+	customers.Get("/:id/ledger/reconcile", ledgerRoutes.Reconcile, read)
 	// catch erros
 
+	read, write = authorize(model.PermissionTransactionRead), authorize(model.PermissionTransactionWrite)
+	void := authorize(model.PermissionTransactionVoid)
 	transactions := h.Group("/transaction")
-	transactions.Post("", transactionRoutes.Create)
-	transactions.Put("/:id", transactionRoutes.Update)
-	transactions.Get("/:id", transactionRoutes.GetByID)
-	transactions.Get("", transactionRoutes.GetAll)
-	transactions.Delete("/:id", transactionRoutes.Delete)
+	transactions.Post("", transactionRoutes.Create, write)
+	transactions.Put("/:id", transactionRoutes.Update, write)
+	transactions.Get("/:id", transactionRoutes.GetByID, read)
+	transactions.Get("", transactionRoutes.GetAll, read)
+	transactions.Delete("/:id", transactionRoutes.Delete, void)
+	transactions.Post("/:id/pay", transactionRoutes.Transition(model.TransactionStatusPaid), write)
+	transactions.Post("/:id/fulfill", transactionRoutes.Transition(model.TransactionStatusFulfilled), write)
+	transactions.Post("/:id/cancel", transactionRoutes.Transition(model.TransactionStatusCancelled), void)
+	transactions.Post("/:id/refund", transactionRoutes.Transition(model.TransactionStatusRefunded), void)
+	transactions.Post("/:id/return", returnRoutes.Create, void)
+	transactions.Get("/:id/return", returnRoutes.GetByTransactionID, read)
+
+	orders := h.Group("/order")
+	orders.Post("", orderRoutes.Create, write)
+	orders.Get("/:id", orderRoutes.GetByID, read)
+	orders.Get("", orderRoutes.GetAll, read)
 
 	transactionsView := h.Group("/transaction-view")
-	h.Get("/transaction-view-filter", transactionRoutes.GetAllTransactionViewByFilters)
+	h.Get("/transaction-view-filter", transactionRoutes.GetAllTransactionViewByFilters, read)
+
+	transactionsView.Get("/search", transactionRoutes.GetAllTransactionViewByFilters, read)
+	transactionsView.Get("/export", transactionRoutes.ExportTransactionViews, read)
+	transactionsView.Get("/:id", transactionRoutes.GetTransactionViewByID, read)
+	transactionsView.Get("", transactionRoutes.GetAllTransactionView, read)
+
+	read = authorize(model.PermissionReportRead)
+	reports := h.Group("/reports")
+	reports.Get("/sales", reportRoutes.Sales, read)
+	reports.Get("/items", reportRoutes.Items, read)
+	reports.Get("/customers", reportRoutes.Customers, read)
+
+	manage := authorize(model.PermissionAPIKeyManage)
+	apiKeys := h.Group("/api-key")
+	apiKeys.Post("", apiKeyRoutes.Create, manage)
+	apiKeys.Get("", apiKeyRoutes.GetAll, manage)
+	apiKeys.Delete("/:id", apiKeyRoutes.Revoke, manage)
+
+	manage = authorize(model.PermissionUserManage)
+	users := h.Group("/user")
+	users.Post("", userRoutes.Create, manage)
+	users.Get("", userRoutes.GetAll, manage)
+	users.Put("/:id", userRoutes.Update, manage)
 
-	transactionsView.Get("/:id", transactionRoutes.GetTransactionViewByID)
-	transactionsView.Get("", transactionRoutes.GetAllTransactionView)
+	h.Get("/audit", auditRoutes.GetAll, authorize(model.PermissionAuditRead))
 }
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type StockRoutes struct {
+	l logger.Interface
+	s service.Stock
+}
+
+func NewStockRoutes(l logger.Interface, s service.Stock) *StockRoutes {
+	return &StockRoutes{l: l, s: s}
+}
+
+type StockOperationRequest struct {
+	Qty    int    `json:"qty"`
+	Reason string `json:"reason"`
+}
+
+func (o *StockOperationRequest) toModel(itemID int) model.StockOperation {
+	return model.StockOperation{
+		ItemID: itemID,
+		Qty:    o.Qty,
+		Reason: o.Reason,
+	}
+}
+
+func (o *StockOperationRequest) validate(receipt bool) error {
+	var err string
+	if receipt && o.Qty < 1 {
+		err += " qty is invalid or less than one,"
+	}
+	if !receipt && o.Qty == 0 {
+		err += " qty is invalid or zero,"
+	}
+	if !receipt && o.Reason == "" {
+		err += " reason is required"
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	} else {
+		return nil
+	}
+}
+
+func (r *StockRoutes) Receive(c fiber.Ctx) error {
+	var operation StockOperationRequest
+	if err := c.Bind().JSON(&operation); err != nil {
+		r.l.Error("StockRoutes - Receive - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err := operation.validate(true)
+	if err != nil {
+		r.l.Error("StockRoutes - Receive - operation.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("StockRoutes - Receive - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	stock, status := r.s.Receive(c.UserContext(), operation.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("StockRoutes - Receive - r.s.Receive:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(gin.H{"item_id": idParamInt, "stock": stock})
+}
+
+func (r *StockRoutes) Adjust(c fiber.Ctx) error {
+	var operation StockOperationRequest
+	if err := c.Bind().JSON(&operation); err != nil {
+		r.l.Error("StockRoutes - Adjust - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err := operation.validate(false)
+	if err != nil {
+		r.l.Error("StockRoutes - Adjust - operation.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("StockRoutes - Adjust - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	stock, status := r.s.Adjust(c.UserContext(), operation.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("StockRoutes - Adjust - r.s.Adjust:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(gin.H{"item_id": idParamInt, "stock": stock})
+}
+
+func (r *StockRoutes) GetMovements(c fiber.Ctx) error {
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("StockRoutes - GetMovements - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	limitF := c.FormValue("limit")
+	offsetF := c.FormValue("offset")
+	limit := 0
+	offset := 0
+	if limitF != "" {
+		limit, err = strconv.Atoi(limitF)
+		if err != nil {
+			r.l.Error("StockRoutes - GetMovements - strconv.Atoi:%w", err)
+			return c.Status(400).JSON(gin.H{"error": "limit is invalid integer"})
+		}
+	}
+	if offsetF != "" {
+		offset, err = strconv.Atoi(offsetF)
+		if err != nil {
+			r.l.Error("StockRoutes - GetMovements - strconv.Atoi:%w", err)
+			return c.Status(400).JSON(gin.H{"error": "offset is invalid integer"})
+		}
+	}
+	result, status := r.s.GetMovements(c.UserContext(), idParamInt, limit, offset)
+	if !status.Ok() {
+		r.l.Error("StockRoutes - GetMovements - r.s.GetMovements:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gofiber/fiber/v3"
@@ -15,28 +17,46 @@ import (
 type TransactionRoutes struct {
 	l logger.Interface
 	s service.Transaction
+	// exportTimeout bounds ExportTransactionViews, the stream outlives the request context
+	exportTimeout time.Duration
 }
 
-func NewTransactionRoutes(l logger.Interface, s service.Transaction) *TransactionRoutes {
-	return &TransactionRoutes{l: l, s: s}
+func NewTransactionRoutes(l logger.Interface, s service.Transaction, exportTimeout time.Duration) *TransactionRoutes {
+	return &TransactionRoutes{l: l, s: s, exportTimeout: exportTimeout}
 }
 
 type TransactionRequest struct {
-	CustomerID int     `json:"customer_id"`
-	ItemID     int     `json:"item_id"`
-	Qty        int     `json:"qty"`
-	Price      float64 `json:"price"`
+	CustomerID    int                   `json:"customer_id"`
+	ItemID        int                   `json:"item_id"`
+	Qty           int                   `json:"qty"`
+	PriceOverride *PriceOverrideRequest `json:"price_override"`
+	// Status is pending or paid, paid by default
+	Status string `json:"status"`
+}
+
+// PriceOverrideRequest replaces the catalog price of the item, it needs the price override permission
+type PriceOverrideRequest struct {
+	Price  model.Money `json:"price"`
+	Reason string      `json:"reason"`
 }
 
 func (t *TransactionRequest) toModel() model.Transaction {
-	return model.Transaction{
+	transaction := model.Transaction{
 		CustomerID: t.CustomerID,
 		ItemID:     t.ItemID,
 		Qty:        t.Qty,
-		Price:      t.Price,
-		Amount:     t.Price * float64(t.Qty),
+		Status:     t.Status,
 		DeletedAt:  nil,
 	}
+	if transaction.Status == "" {
+		transaction.Status = model.TransactionStatusPaid
+	}
+	// price and amount are set by the service from the item catalog unless overridden
+	if t.PriceOverride != nil {
+		transaction.Price = t.PriceOverride.Price
+		transaction.PriceOverrideReason = &t.PriceOverride.Reason
+	}
+	return transaction
 }
 
 func (t *TransactionRequest) validate() error {
@@ -51,8 +71,13 @@ func (t *TransactionRequest) validate() error {
 	if t.Qty < 1 {
 		err += " qty is invalid,"
 	}
-	if t.Price <= 0 {
-		err += " price is invalid or under zero,"
+	if t.PriceOverride != nil {
+		if t.PriceOverride.Price <= 0 {
+			err += " override price is invalid or under zero,"
+		}
+		if t.PriceOverride.Reason == "" {
+			err += " override reason is required,"
+		}
 	}
 	if len(err) != 0 {
 		return errors.New(err)
@@ -67,7 +92,11 @@ func (r *TransactionRoutes) Create(c fiber.Ctx) error {
 		r.l.Error("TransactionRoutes - Create - c.Bind.JSON:%w", err)
 		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
 	}
-	id, status := r.s.Create(c.Context(), transaction.toModel())
+	if err := transaction.validate(); err != nil {
+		r.l.Error("TransactionRoutes - Create - transaction.validate:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
+	id, status := r.s.Create(c.UserContext(), transaction.toModel())
 	if !status.Ok() {
 		r.l.Error("TransactionRoutes - Create - r.s.Create:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -81,6 +110,10 @@ func (r *TransactionRoutes) Update(c fiber.Ctx) error {
 		r.l.Error("TransactionRoutes - Update - ctx.ShouldBindJSON:%w", err)
 		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
 	}
+	if err := transaction.validate(); err != nil {
+		r.l.Error("TransactionRoutes - Update - transaction.validate:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
+	}
 	idParam := c.Params("id")
 	idParamInt, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -89,7 +122,7 @@ func (r *TransactionRoutes) Update(c fiber.Ctx) error {
 	}
 	transactionModel := transaction.toModel()
 	transactionModel.ID = idParamInt
-	result, status := r.s.Update(c.Context(), transactionModel)
+	result, status := r.s.Update(c.UserContext(), transactionModel)
 	if !status.Ok() {
 		r.l.Error("TransactionRoutes - Update - r.s.Update:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -104,7 +137,7 @@ func (r *TransactionRoutes) GetByID(c fiber.Ctx) error {
 		r.l.Error("TransactionRoutes - GetByID - parseInt:%w", err)
 		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	result, status := r.s.GetByID(c.Context(), idParamInt)
+	result, status := r.s.GetByID(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error("TransactionRoutes - GetByID - r.s.GetByID:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -122,6 +155,14 @@ func (r *TransactionRoutes) GetAll(c fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "limit is invalid integer"})
 		}
 	}
+	if cursor, ok := cursorQuery(c); ok {
+		result, status := r.s.GetPage(c.UserContext(), cursor, limit)
+		if !status.Ok() {
+			r.l.Error("TransactionRoutes - GetAll - r.s.GetPage:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		return c.Status(status.Code).JSON(result)
+	}
 	offset, err := strconv.Atoi(offsetF)
 	if offsetF != "" {
 		if err != nil {
@@ -129,7 +170,7 @@ func (r *TransactionRoutes) GetAll(c fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "offset is invalid integer"})
 		}
 	}
-	result, status := r.s.GetAll(c.Context(), limit, offset)
+	result, status := r.s.GetAll(c.UserContext(), limit, offset)
 	if !status.Ok() {
 		r.l.Error("TransactionRoutes - GetAll - r.s.GetAll:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -144,7 +185,8 @@ func (r *TransactionRoutes) Delete(c fiber.Ctx) error {
 		r.l.Error("TransactionRoutes - Delete - parseInt:%w", err)
 		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	status := r.s.Delete(c.Context(), idParamInt)
+	reason := c.Query("reason", "transaction voided")
+	status := r.s.Delete(c.UserContext(), idParamInt, reason)
 	if !status.Ok() {
 		r.l.Error("TransactionRoutes - Delete - r.s.Delete:%w", status.Err)
 		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
@@ -152,6 +194,34 @@ func (r *TransactionRoutes) Delete(c fiber.Ctx) error {
 	return c.Status(status.Code).Send([]byte(status.Msg))
 }
 
+type TransitionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Transition returns a handler moving the transaction to the status to
+func (r *TransactionRoutes) Transition(to string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		idParamInt, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			r.l.Error("TransactionRoutes - Transition - parseInt:%w", err)
+			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "id is invalid integer"})
+		}
+		request := TransitionRequest{Reason: "transaction " + to}
+		if len(c.Body()) > 0 {
+			if err := c.Bind().JSON(&request); err != nil {
+				r.l.Error("TransactionRoutes - Transition - c.Bind.JSON:%w", err)
+				return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
+			}
+		}
+		result, status := r.s.Transition(c.UserContext(), idParamInt, to, request.Reason)
+		if !status.Ok() {
+			r.l.Error("TransactionRoutes - Transition - r.s.Transition:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		return c.Status(status.Code).JSON(result)
+	}
+}
+
 func (r *TransactionRoutes) GetTransactionViewByID(c fiber.Ctx) error {
 	idParam := c.Params("id")
 	idParamInt, err := strconv.Atoi(idParam)
@@ -159,7 +229,7 @@ func (r *TransactionRoutes) GetTransactionViewByID(c fiber.Ctx) error {
 		r.l.Error("TransactionRoutes - GetTransactionViewByID - parseInt:%w", err)
 		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "id is invalid integer"})
 	}
-	result, status := r.s.GetByTransactionID(c.Context(), idParamInt)
+	result, status := r.s.GetByTransactionID(c.UserContext(), idParamInt)
 	if !status.Ok() {
 		r.l.Error(
 			"TransactionRoutes - GetTransactionViewByID - r.s.GetByTransactionID:%w",
@@ -183,6 +253,14 @@ func (r *TransactionRoutes) GetAllTransactionView(c fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "limit is invalid integer"})
 		}
 	}
+	if cursor, ok := cursorQuery(c); ok {
+		result, status := r.s.GetTransactionViewPage(c.UserContext(), cursor, limit)
+		if !status.Ok() {
+			r.l.Error("TransactionRoutes - GetAllTransactionView - r.s.GetTransactionViewPage:%w", status.Err)
+			return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+		}
+		return c.Status(status.Code).JSON(result)
+	}
 	if offsetF != "" {
 		offset, err = strconv.Atoi(offsetF)
 		if err != nil {
@@ -190,7 +268,7 @@ func (r *TransactionRoutes) GetAllTransactionView(c fiber.Ctx) error {
 			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "offset is invalid integer"})
 		}
 	}
-	result, status := r.s.GetAllTransactionViews(c.Context(), limit, offset)
+	result, status := r.s.GetAllTransactionViews(c.UserContext(), limit, offset)
 	if !status.Ok() {
 		r.l.Error(
 			"TransactionRoutes - GetAllTransactionView - r.s.GetAllTransactionViews:%w",
@@ -201,14 +279,22 @@ func (r *TransactionRoutes) GetAllTransactionView(c fiber.Ctx) error {
 	return c.Status(status.Code).JSON(result)
 }
 
+// GetAllTransactionViewByFilters searches transaction views by the query string, see parseTransactionFilter
 func (r *TransactionRoutes) GetAllTransactionViewByFilters(c fiber.Ctx) error {
 	filter := model.TransactionFilter{}
-	err := c.Bind().JSON(&filter)
+	// the filter used to be sent as a JSON body, it is still accepted and the query string wins
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&filter); err != nil {
+			r.l.Error("TransactionRoutes - GetAllTransactionViewByFilters - c.Bind:%w", err)
+			return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
+		}
+	}
+	err := parseTransactionFilter(c, &filter)
 	if err != nil {
-		r.l.Error("TransactionRoutes - GetAllTransactionViewByFilters - c.Bind:%w", err)
-		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": "invalid request"})
+		r.l.Error("TransactionRoutes - GetAllTransactionViewByFilters - parseTransactionFilter:%w", err)
+		return c.Status(http.StatusBadRequest).JSON(gin.H{"error": err.Error()})
 	}
-	result, status := r.s.GetAllTransactionViewsByFilters(c.Context(), &filter)
+	result, status := r.s.GetAllTransactionViewsByFilters(c.UserContext(), &filter)
 	if !status.Ok() {
 		r.l.Error(
 			"TransactionRoutes - GetAllTransactionViewByFilters - r.s.GetAllTransactionViewsByFilters:%w",
@@ -218,3 +304,108 @@ func (r *TransactionRoutes) GetAllTransactionViewByFilters(c fiber.Ctx) error {
 	}
 	return c.Status(status.Code).JSON(result)
 }
+
+// parseTransactionFilter reads the search from the query string:
+//
+//	id, customer_name, item_name
+//	customer_id, item_id, status      comma separated lists, e.g. customer_id=1,2
+//	created_from, created_to          RFC 3339 time or a YYYY-MM-DD date, a created_to date includes the whole day
+//	amount_min, amount_max            decimal amounts
+//	qty_min, qty_max
+//	sort                              comma separated fields with an optional direction, e.g. sort=amount:desc,id
+//	limit, offset
+func parseTransactionFilter(c fiber.Ctx, filter *model.TransactionFilter) error {
+	var errs []string
+	intPtrParam := func(key string, dst **int) {
+		if v := c.Query(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, key+" is invalid integer")
+				return
+			}
+			*dst = &n
+		}
+	}
+	intParam := func(key string, dst *int) {
+		var n *int
+		intPtrParam(key, &n)
+		if n != nil {
+			*dst = *n
+		}
+	}
+	intListParam := func(key string, dst *[]int) {
+		v := c.Query(key)
+		if v == "" {
+			return
+		}
+		*dst = nil
+		for _, part := range strings.Split(v, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				errs = append(errs, key+" is invalid integer list")
+				return
+			}
+			*dst = append(*dst, n)
+		}
+	}
+	moneyParam := func(key string, dst **model.Money) {
+		if v := c.Query(key); v != "" {
+			m, err := model.ParseMoney(v)
+			if err != nil {
+				errs = append(errs, key+" is invalid amount")
+				return
+			}
+			*dst = &m
+		}
+	}
+	timeParam := func(key string, dst **time.Time, endOfDay bool) {
+		t, err := timeQuery(c, key, endOfDay)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		if t != nil {
+			*dst = t
+		}
+	}
+
+	intParam("id", &filter.ID)
+	if v := c.Query("customer_name"); v != "" {
+		filter.CustomerName = v
+	}
+	if v := c.Query("item_name"); v != "" {
+		filter.ItemName = v
+	}
+	intListParam("customer_id", &filter.CustomerIDs)
+	intListParam("item_id", &filter.ItemIDs)
+	if v := c.Query("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+	timeParam("created_from", &filter.CreatedFrom, false)
+	timeParam("created_to", &filter.CreatedTo, true)
+	moneyParam("amount_min", &filter.AmountMin)
+	moneyParam("amount_max", &filter.AmountMax)
+	intPtrParam("qty_min", &filter.QtyMin)
+	intPtrParam("qty_max", &filter.QtyMax)
+	if v := c.Query("sort"); v != "" {
+		filter.Sort = nil
+		for _, part := range strings.Split(v, ",") {
+			field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+			switch strings.ToLower(direction) {
+			case "", "asc":
+				filter.Sort = append(filter.Sort, model.TransactionSort{Field: field})
+			case "desc":
+				filter.Sort = append(filter.Sort, model.TransactionSort{Field: field, Desc: true})
+			default:
+				errs = append(errs, "sort direction of "+field+" must be asc or desc")
+			}
+		}
+	}
+	intParam("limit", &filter.Limit)
+	intParam("offset", &filter.Offset)
+
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v3"
+	"github.com/robertt3kuk/xiaoma-test-task/init/logger"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/service"
+)
+
+type UserRoutes struct {
+	l logger.Interface
+	s service.User
+}
+
+func NewUserRoutes(l logger.Interface, s service.User) *UserRoutes {
+	return &UserRoutes{l: l, s: s}
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type UserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+func (u *UserRequest) toModel() model.User {
+	return model.User{
+		Username: u.Username,
+		Role:     u.Role,
+	}
+}
+
+func (u *UserRequest) validate() error {
+	var err string
+	if u.Username == "" {
+		err += " username is required,"
+	}
+	if u.Password == "" {
+		err += " password is required,"
+	}
+	if !model.IsRole(u.Role) {
+		err += " role must be auditor, cashier, manager or admin"
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	}
+	return nil
+}
+
+// UserUpdateRequest changes the fields that are set
+type UserUpdateRequest struct {
+	Role     *string `json:"role"`
+	Password *string `json:"password"`
+	Disabled *bool   `json:"disabled"`
+}
+
+func (u *UserUpdateRequest) toModel(id int) model.UserUpdate {
+	return model.UserUpdate{
+		ID:       id,
+		Role:     u.Role,
+		Password: u.Password,
+		Disabled: u.Disabled,
+	}
+}
+
+func (r *UserRoutes) Login(c fiber.Ctx) error {
+	var request LoginRequest
+	if err := c.Bind().JSON(&request); err != nil {
+		r.l.Error("UserRoutes - Login - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	if request.Username == "" || request.Password == "" {
+		return c.Status(400).JSON(gin.H{"error": "username and password are required"})
+	}
+	result, status := r.s.Login(c.UserContext(), request.Username, request.Password)
+	if !status.Ok() {
+		r.l.Error("UserRoutes - Login - r.s.Login:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+// Me returns the actor of the request, a user or an api key, with its permissions
+func (r *UserRoutes) Me(c fiber.Ctx) error {
+	return c.Status(200).JSON(service.ActorFromContext(c.UserContext()))
+}
+
+func (r *UserRoutes) Create(c fiber.Ctx) error {
+	var request UserRequest
+	if err := c.Bind().JSON(&request); err != nil {
+		r.l.Error("UserRoutes - Create - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	err := request.validate()
+	if err != nil {
+		r.l.Error("UserRoutes - Create - request.validate:%w", err)
+		return c.Status(400).JSON(gin.H{"error": err.Error()})
+	}
+	result, status := r.s.Create(c.UserContext(), request.toModel(), request.Password)
+	if !status.Ok() {
+		r.l.Error("UserRoutes - Create - r.s.Create:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *UserRoutes) GetAll(c fiber.Ctx) error {
+	result, status := r.s.GetAll(c.UserContext())
+	if !status.Ok() {
+		r.l.Error("UserRoutes - GetAll - r.s.GetAll:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
+
+func (r *UserRoutes) Update(c fiber.Ctx) error {
+	idParamInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		r.l.Error("UserRoutes - Update - parseInt:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "id is invalid integer"})
+	}
+	var request UserUpdateRequest
+	if err := c.Bind().JSON(&request); err != nil {
+		r.l.Error("UserRoutes - Update - c.Bind.JSON:%w", err)
+		return c.Status(400).JSON(gin.H{"error": "invalid request"})
+	}
+	result, status := r.s.Update(c.UserContext(), request.toModel(idParamInt))
+	if !status.Ok() {
+		r.l.Error("UserRoutes - Update - r.s.Update:%w", status.Err)
+		return c.Status(status.Code).JSON(gin.H{"error": status.Msg})
+	}
+	return c.Status(status.Code).JSON(result)
+}
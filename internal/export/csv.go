@@ -0,0 +1,30 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+type csvWriter struct {
+	w      *csv.Writer
+	loc    *time.Location
+	record []string
+}
+
+func newCSVWriter(w io.Writer, loc *time.Location) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), loc: loc}
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	c.record = c.record[:0]
+	for _, v := range values {
+		c.record = append(c.record, formatValue(v, c.loc))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,58 @@
+// Package export writes tabular data as CSV or XLSX row by row, so a result set
+// can be streamed to the client without holding it in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes maps the formats to their media types
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Writer writes a header row followed by data rows, Close must be called to finish the file.
+// Row values are strings, ints, int64s, float64s or time.Times, anything else is written with fmt.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// NewWriter returns a Writer of format on w, times are written in loc
+func NewWriter(format string, w io.Writer, loc *time.Location) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, loc), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, loc)
+	}
+	return nil, fmt.Errorf("export - NewWriter: unknown format %q", format)
+}
+
+// formatValue renders a value as text, it is used for CSV cells and XLSX string cells
+func formatValue(v any, loc *time.Location) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.In(loc).Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.In(loc).Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
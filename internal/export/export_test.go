@@ -0,0 +1,252 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+var testLocation = time.FixedZone("UTC+5", 5*60*60)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		i    int
+		want string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+		// the last column of a sheet
+		{16383, "XFD"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.i); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"nil", nil, ""},
+		{"string", "a,b", "a,b"},
+		{"int", 42, "42"},
+		{"negative int", -7, "-7"},
+		{"float", 1.5, "1.5"},
+		{"time in loc", at, "2024-03-10T17:00:00+05:00"},
+		{"time pointer", &at, "2024-03-10T17:00:00+05:00"},
+		{"nil time pointer", (*time.Time)(nil), ""},
+		{"money", model.Money(1230), "12.30"},
+		{"negative money", model.Money(-5), "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.v, testLocation); got != tt.want {
+			t.Errorf("%s: formatValue(%v) = %q, want %q", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("pdf", io.Discard, time.UTC); err == nil {
+		t.Error("NewWriter(pdf): got nil error")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rows [][]any
+		want string
+	}{
+		{"empty", nil, ""},
+		{"header", [][]any{{"id", "name"}}, "id,name\n"},
+		{
+			"values",
+			[][]any{{1, "item", model.Money(-1234), at, (*time.Time)(nil), nil}},
+			"1,item,-12.34,2024-03-10T17:00:00+05:00,,\n",
+		},
+		{"quoting", [][]any{{`a,b`, `say "hi"`, "two\nlines"}}, "\"a,b\",\"say \"\"hi\"\"\",\"two\nlines\"\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := NewWriter(FormatCSV, &buf, testLocation)
+		if err != nil {
+			t.Fatalf("%s: NewWriter: %v", tt.name, err)
+		}
+		for _, row := range tt.rows {
+			if err := w.WriteRow(row); err != nil {
+				t.Fatalf("%s: WriteRow: %v", tt.name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close: %v", tt.name, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: wrote %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		rows  [][]any
+		cells []string
+	}{
+		{"empty", nil, nil},
+		{"header", [][]any{{"id", "name"}}, []string{
+			`<c r="A1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`,
+			`<c r="B1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c>`,
+		}},
+		{"numbers", [][]any{{1, int64(-2), 1.5, model.Money(-1234)}}, []string{
+			`<c r="A1"><v>1</v></c>`,
+			`<c r="B1"><v>-2</v></c>`,
+			`<c r="C1"><v>1.5</v></c>`,
+			`<c r="D1"><v>-12.34</v></c>`,
+		}},
+		{"strings", [][]any{{"a<b & c", at, (*time.Time)(nil), nil}}, []string{
+			`<c r="A1" t="inlineStr"><is><t xml:space="preserve">a&lt;b &amp; c</t></is></c>`,
+			`<c r="B1" t="inlineStr"><is><t xml:space="preserve">2024-03-10T17:00:00+05:00</t></is></c>`,
+			`<c r="C1" t="inlineStr"><is><t xml:space="preserve"></t></is></c>`,
+			`<c r="D1" t="inlineStr"><is><t xml:space="preserve"></t></is></c>`,
+		}},
+		{"rows", [][]any{{"id"}, {1}, {2}}, []string{
+			`<row r="1"><c r="A1" t="inlineStr">`,
+			`<row r="2"><c r="A2"><v>1</v></c></row>`,
+			`<row r="3"><c r="A3"><v>2</v></c></row>`,
+		}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := NewWriter(FormatXLSX, &buf, testLocation)
+		if err != nil {
+			t.Fatalf("%s: NewWriter: %v", tt.name, err)
+		}
+		for _, row := range tt.rows {
+			if err := w.WriteRow(row); err != nil {
+				t.Fatalf("%s: WriteRow: %v", tt.name, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close: %v", tt.name, err)
+		}
+		sheet := readXLSX(t, buf.Bytes())
+		for _, cell := range tt.cells {
+			if !strings.Contains(sheet, cell) {
+				t.Errorf("%s: sheet doesn't contain %s:\n%s", tt.name, cell, sheet)
+			}
+		}
+	}
+}
+
+// readXLSX checks that data is a workbook of well formed parts and returns its sheet
+func readXLSX(t *testing.T, data []byte) string {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	parts := make(map[string]string, len(z.File))
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well formed: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(body)
+	}
+	for _, part := range xlsxParts {
+		if _, ok := parts[part.name]; !ok {
+			t.Errorf("workbook has no %s", part.name)
+		}
+	}
+	sheet, ok := parts["xl/worksheets/sheet1.xml"]
+	if !ok {
+		t.Fatal("workbook has no sheet")
+	}
+	return sheet
+}
+
+func TestWriteTransactionViews(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	views := []model.TransactionView{
+		{ID: 1, CustomerName: "alice", ItemName: "tea", Qty: 2, Price: 150, Amount: 300, Status: "paid", CreatedAt: at},
+		{ID: 2, CustomerName: "bob, jr", ItemName: "cake", Qty: 1, Price: 99, Amount: 99, Status: "pending", CreatedAt: at},
+	}
+	stream := func(ctx context.Context, fn func(model.TransactionView) error) error {
+		for _, v := range views {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	columns := []string{"id", "customer_name", "amount", "created_at"}
+	if err := WriteTransactionViews(context.Background(), &buf, stream, FormatCSV, columns, testLocation); err != nil {
+		t.Fatalf("WriteTransactionViews: %v", err)
+	}
+	want := "id,customer_name,amount,created_at\n" +
+		"1,alice,3.00,2024-03-10T17:00:00+05:00\n" +
+		"2,\"bob, jr\",0.99,2024-03-10T17:00:00+05:00\n"
+	if got := buf.String(); got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+
+	errStream := errors.New("stream failed")
+	failing := func(context.Context, func(model.TransactionView) error) error { return errStream }
+	err := WriteTransactionViews(context.Background(), io.Discard, failing, FormatCSV, columns, testLocation)
+	if !errors.Is(err, errStream) {
+		t.Errorf("WriteTransactionViews with a failing stream: got %v, want %v", err, errStream)
+	}
+	if err := WriteTransactionViews(context.Background(), io.Discard, stream, "pdf", columns, testLocation); err == nil {
+		t.Error("WriteTransactionViews(pdf): got nil error")
+	}
+}
+
+func TestTransactionViewColumns(t *testing.T) {
+	for _, column := range TransactionViewColumns {
+		if !IsTransactionViewColumn(column) {
+			t.Errorf("default column %s can't be exported", column)
+		}
+	}
+	for _, column := range []string{"", "deleted_at", "ID", "password"} {
+		if IsTransactionViewColumn(column) {
+			t.Errorf("IsTransactionViewColumn(%q) = true, want false", column)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+// TransactionViewColumns lists the exportable columns of a transaction view in their default order
+var TransactionViewColumns = []string{
+	"id", "customer_id", "customer_name", "item_id", "item_name", "qty", "price", "amount", "status",
+	"created_at", "updated_at",
+}
+
+var transactionViewValues = map[string]func(v model.TransactionView) any{
+	"id":            func(v model.TransactionView) any { return v.ID },
+	"customer_id":   func(v model.TransactionView) any { return v.CustomerID },
+	"customer_name": func(v model.TransactionView) any { return v.CustomerName },
+	"item_id":       func(v model.TransactionView) any { return v.ItemID },
+	"item_name":     func(v model.TransactionView) any { return v.ItemName },
+	"qty":           func(v model.TransactionView) any { return v.Qty },
+	"price":         func(v model.TransactionView) any { return v.Price },
+	"amount":        func(v model.TransactionView) any { return v.Amount },
+	"status":        func(v model.TransactionView) any { return v.Status },
+	"created_at":    func(v model.TransactionView) any { return v.CreatedAt },
+	"updated_at":    func(v model.TransactionView) any { return v.UpdatedAt },
+}
+
+// IsTransactionViewColumn reports whether column can be exported
+func IsTransactionViewColumn(column string) bool {
+	_, ok := transactionViewValues[column]
+	return ok
+}
+
+// TransactionViewRow returns the values of the columns of v, columns must be known
+func TransactionViewRow(v model.TransactionView, columns []string) []any {
+	row := make([]any, len(columns))
+	for i, column := range columns {
+		row[i] = transactionViewValues[column](v)
+	}
+	return row
+}
+
+// WriteTransactionViews writes the header of columns and then every view of stream to w in format,
+// times are written in loc. w is written row by row, callers buffer it themselves.
+func WriteTransactionViews(
+	ctx context.Context,
+	w io.Writer,
+	stream func(context.Context, func(model.TransactionView) error) error,
+	format string,
+	columns []string,
+	loc *time.Location,
+) error {
+	writer, err := NewWriter(format, w, loc)
+	if err != nil {
+		return fmt.Errorf("NewWriter: %w", err)
+	}
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := writer.WriteRow(header); err != nil {
+		return fmt.Errorf("writer.WriteRow: %w", err)
+	}
+	err = stream(ctx, func(v model.TransactionView) error {
+		return writer.WriteRow(TransactionViewRow(v, columns))
+	})
+	if err != nil {
+		return fmt.Errorf("stream: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writer.Close: %w", err)
+	}
+	return nil
+}
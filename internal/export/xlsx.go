@@ -0,0 +1,118 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// xlsxWriter writes a single sheet workbook. The sheet is the last zip entry so its rows can be
+// written as they come, strings are stored inline instead of in a shared strings table.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	loc   *time.Location
+	rows  int
+}
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+func newXLSXWriter(w io.Writer, loc *time.Location) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("export - newXLSXWriter - z.Create: %w", err)
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, fmt.Errorf("export - newXLSXWriter - io.WriteString: %w", err)
+		}
+	}
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("export - newXLSXWriter - z.Create: %w", err)
+	}
+	sheet := bufio.NewWriter(f)
+	_, err = sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, fmt.Errorf("export - newXLSXWriter - sheet.WriteString: %w", err)
+	}
+	return &xlsxWriter{zip: z, sheet: sheet, loc: loc}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.rows)
+		switch v := v.(type) {
+		case int, int64, float64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%v</v></c>`, ref, v)
+		case nil, string, time.Time, *time.Time:
+			x.writeString(ref, formatValue(v, x.loc))
+		case json.Marshaler:
+			// decimal amounts such as model.Money are written as numbers
+			data, err := v.MarshalJSON()
+			if err != nil {
+				return fmt.Errorf("export - WriteRow - MarshalJSON: %w", err)
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, data)
+		default:
+			x.writeString(ref, formatValue(v, x.loc))
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// writeString writes an inline string cell, write errors surface on the next flush
+func (x *xlsxWriter) writeString(ref, s string) {
+	fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	xml.EscapeText(x.sheet, []byte(s))
+	x.sheet.WriteString(`</t></is></c>`)
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return fmt.Errorf("export - Close - sheet.WriteString: %w", err)
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return fmt.Errorf("export - Close - sheet.Flush: %w", err)
+	}
+	return x.zip.Close()
+}
+
+// columnName returns the spreadsheet name of the zero based column i: A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,98 @@
+// Package health runs the readiness checks of the app's dependencies.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+const _defaultCheckTimeout = 2 * time.Second
+
+// Check returns nil when the component is usable
+type Check func(ctx context.Context) error
+
+// Component is the result of one check
+type Component struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the readiness of the app, it is up when every component is up and the app is not draining
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Ok reports whether the app can take traffic
+func (r Report) Ok() bool {
+	return r.Status == StatusUp
+}
+
+// Checker -.
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{
+		timeout: _defaultCheckTimeout,
+		checks:  map[string]Check{},
+	}
+}
+
+// Add registers a check under name, checks must be added before the first Ready call
+func (h *Checker) Add(name string, check Check) {
+	h.names = append(h.names, name)
+	h.checks[name] = check
+}
+
+// Drain makes Ready fail from now on, it is called when the shutdown starts so the load
+// balancer stops sending requests before the server stops accepting them
+func (h *Checker) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs the checks concurrently, each one is limited to the check timeout
+func (h *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(h.names))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range h.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			component := Component{Status: StatusUp, Duration: time.Since(start).String()}
+			if err != nil {
+				component.Status = StatusDown
+				component.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(name, h.checks[name])
+	}
+	wg.Wait()
+	if h.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
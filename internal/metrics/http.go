@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests, by method, route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// NewHTTPMiddleware observes every request, the route is the registered pattern like
+// /v1/item/:id so the ids don't end up in the labels, requests that match no route are
+// reported under the route of the middleware itself, /
+func NewHTTPMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		code := c.Response().StatusCode()
+		// the error handler writes the status after the middleware returns
+		if err != nil {
+			code = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				code = fiberErr.Code
+			}
+		}
+		labels := []string{c.Method(), c.Route().Path, strconv.Itoa(code)}
+		httpRequests.WithLabelValues(labels...).Inc()
+		httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
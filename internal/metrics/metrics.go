@@ -0,0 +1,142 @@
+// Package metrics holds the Prometheus collectors of the app and the handler that exposes them.
+//
+// Everything is registered on Registry rather than on the global default registry, so the
+// admin CLI and the migrations, which share the service layer, never expose anything.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+const namespace = "xiaoma"
+
+// Registry holds the collectors served by Handler
+var Registry = prometheus.NewRegistry()
+
+var (
+	transactionsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Transactions created, by their initial status.",
+	}, []string{"status"})
+	ordersCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "Orders created.",
+	})
+	balanceCheckFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "balance_check_failures_total",
+		Help:      "Operations rejected because the customer balance was not enough, by operation.",
+	}, []string{"operation"})
+	refunds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Voids, refunds and returns, by kind.",
+	}, []string{"kind"})
+	refundedAmount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunded_amount_total",
+		Help:      "Amount given back to customers by refunds and returns, by kind.",
+	}, []string{"kind"})
+	revenue = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revenue_total",
+		Help:      "Amount charged to customers, by source.",
+	}, []string{"source"})
+	auditFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_failures_total",
+		Help:      "Changes rolled back because their audit entry couldn't be written, by entity.",
+	}, []string{"entity"})
+)
+
+// operations that check the customer balance
+const (
+	OperationTransactionCreate = "transaction_create"
+	OperationTransactionUpdate = "transaction_update"
+	OperationTransactionPay    = "transaction_pay"
+	OperationOrderCreate       = "order_create"
+	OperationWithdraw          = "withdraw"
+)
+
+// kinds of refunds
+const (
+	RefundVoid   = "void"
+	RefundRefund = "refund"
+	RefundReturn = "return"
+)
+
+// sources of revenue
+const (
+	RevenueTransaction = "transaction"
+	RevenueOrder       = "order"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		transactionsCreated,
+		ordersCreated,
+		balanceCheckFailures,
+		refunds,
+		refundedAmount,
+		revenue,
+		auditFailures,
+	)
+}
+
+// Handler serves Registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// TransactionCreated counts a new transaction, a paid one is charged right away
+func TransactionCreated(status string, amount model.Money) {
+	transactionsCreated.WithLabelValues(status).Inc()
+	if status == model.TransactionStatusPaid {
+		Charged(RevenueTransaction, amount)
+	}
+}
+
+// OrderCreated counts a new order, orders are always paid on creation
+func OrderCreated(total model.Money) {
+	ordersCreated.Inc()
+	Charged(RevenueOrder, total)
+}
+
+// Charged adds amount to the revenue of source
+func Charged(source string, amount model.Money) {
+	revenue.WithLabelValues(source).Add(moneyValue(amount))
+}
+
+// BalanceCheckFailed counts an operation rejected for insufficient balance
+func BalanceCheckFailed(operation string) {
+	balanceCheckFailures.WithLabelValues(operation).Inc()
+}
+
+// Refunded counts a refund of kind and adds its amount to the refunded total
+func Refunded(kind string, amount model.Money) {
+	refunds.WithLabelValues(kind).Inc()
+	if amount > 0 {
+		refundedAmount.WithLabelValues(kind).Add(moneyValue(amount))
+	}
+}
+
+// AuditFailed counts a change of entity rolled back because its audit entry couldn't be written
+func AuditFailed(entity string) {
+	auditFailures.WithLabelValues(entity).Inc()
+}
+
+// moneyValue converts cents to currency units, counters can only hold floats
+func moneyValue(m model.Money) float64 {
+	return float64(m) / 100
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exports the pgxpool statistics, they are read from Pool.Stat on every scrape
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	idleConns            *prometheus.Desc
+	maxConns             *prometheus.Desc
+	totalConns           *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroy   *prometheus.Desc
+	maxIdleDestroy       *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		pool:                 pool,
+		acquireCount:         desc("acquire_total", "Successful acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Time spent on successful acquires."),
+		acquiredConns:        desc("acquired_conns", "Connections currently acquired."),
+		canceledAcquireCount: desc("canceled_acquire_total", "Acquires canceled by their context."),
+		constructingConns:    desc("constructing_conns", "Connections being opened."),
+		emptyAcquireCount:    desc("empty_acquire_total", "Acquires that had to wait for a connection."),
+		idleConns:            desc("idle_conns", "Connections currently idle."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		totalConns:           desc("total_conns", "Connections open in the pool."),
+		newConnsCount:        desc("new_conns_total", "Connections opened."),
+		maxLifetimeDestroy:   desc("max_lifetime_destroy_total", "Connections closed for exceeding MaxConnLifetime."),
+		maxIdleDestroy:       desc("max_idle_destroy_total", "Connections closed for exceeding MaxConnIdleTime."),
+	}
+}
+
+func (p *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(p, ch)
+}
+
+func (p *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.pool.Stat()
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter(p.acquireCount, float64(stat.AcquireCount()))
+	counter(p.acquireDuration, stat.AcquireDuration().Seconds())
+	gauge(p.acquiredConns, float64(stat.AcquiredConns()))
+	counter(p.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	gauge(p.constructingConns, float64(stat.ConstructingConns()))
+	counter(p.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	gauge(p.idleConns, float64(stat.IdleConns()))
+	gauge(p.maxConns, float64(stat.MaxConns()))
+	gauge(p.totalConns, float64(stat.TotalConns()))
+	counter(p.newConnsCount, float64(stat.NewConnsCount()))
+	counter(p.maxLifetimeDestroy, float64(stat.MaxLifetimeDestroyCount()))
+	counter(p.maxIdleDestroy, float64(stat.MaxIdleDestroyCount()))
+}
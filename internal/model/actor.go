@@ -0,0 +1,37 @@
+package model
+
+type Permission string
+
+const (
+	PermissionItemRead         Permission = "item:read"
+	PermissionItemWrite        Permission = "item:write"
+	PermissionCustomerRead     Permission = "customer:read"
+	PermissionCustomerWrite    Permission = "customer:write"
+	PermissionCustomerBalance  Permission = "customer:balance"
+	PermissionTransactionRead  Permission = "transaction:read"
+	PermissionTransactionWrite Permission = "transaction:write"
+	// PermissionTransactionVoid covers voids, cancellations, refunds and returns
+	PermissionTransactionVoid Permission = "transaction:void"
+	PermissionPriceOverride   Permission = "transaction:price_override"
+	PermissionReportRead      Permission = "report:read"
+	PermissionAuditRead       Permission = "audit:read"
+	PermissionAPIKeyManage    Permission = "api_key:manage"
+	PermissionUserManage      Permission = "user:manage"
+)
+
+// Actor is whoever performs the request, an empty actor has no permissions
+type Actor struct {
+	Name string `json:"name"`
+	// TenantID is the tenant the credentials belong to, 0 for a platform api key
+	TenantID    int          `json:"tenant_id"`
+	Permissions []Permission `json:"permissions"`
+}
+
+func (a Actor) Can(permission Permission) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	APIKeyScopeReadOnly = "read_only"
+	APIKeyScopeCashier  = "cashier"
+	APIKeyScopeAdmin    = "admin"
+)
+
+// APIKeyScopePermissions maps the scopes of the API keys to what they allow,
+// they match the user roles of the same power
+var APIKeyScopePermissions = map[string][]Permission{
+	APIKeyScopeReadOnly: RolePermissions[RoleAuditor],
+	APIKeyScopeCashier:  RolePermissions[RoleCashier],
+	APIKeyScopeAdmin:    RolePermissions[RoleAdmin],
+}
+
+// APIKey is a stored key, Prefix is the start of the key so it can be recognized in listings
+//
+//swagger:model
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	// TenantID is nil for a platform key, it picks the tenant of each request with a header
+	TenantID *int `json:"tenant_id"`
+}
+
+// NewAPIKey is a created key with its secret, the secret can't be read back later
+//
+//swagger:model
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Actor returns the actor of requests made with the key, it is named by the id since names aren't unique
+func (k APIKey) Actor() Actor {
+	actor := Actor{Name: "api_key:" + strconv.Itoa(k.ID), Permissions: APIKeyScopePermissions[k.Scope]}
+	if k.TenantID != nil {
+		actor.TenantID = *k.TenantID
+	}
+	return actor
+}
+
+func IsAPIKeyScope(scope string) bool {
+	_, ok := APIKeyScopePermissions[scope]
+	return ok
+}
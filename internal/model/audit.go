@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+const (
+	AuditEntityItem        = "item"
+	AuditEntityCustomer    = "customer"
+	AuditEntityTransaction = "transaction"
+)
+
+// AuditEntry records one change of an item, customer or transaction. Before is null for a
+// creation and After for a deletion, both hold the JSON of the row as the API returns it.
+//
+//swagger:model
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	TenantID  int             `json:"tenant_id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter searches the audit log, zero fields are not filtered on and ranges include their bounds
+type AuditFilter struct {
+	Actor       string     `json:"actor"`
+	Action      string     `json:"action"`
+	Entity      string     `json:"entity"`
+	EntityID    int        `json:"entity_id"`
+	RequestID   string     `json:"request_id"`
+	CreatedFrom *time.Time `json:"created_from"`
+	CreatedTo   *time.Time `json:"created_to"`
+	Limit       int        `json:"limit"`
+	Offset      int        `json:"offset"`
+}
+
+//swagger:model
+type AuditPage struct {
+	Items  []AuditEntry `json:"items"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+func IsAuditAction(action string) bool {
+	switch action {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete:
+		return true
+	}
+	return false
+}
+
+func IsAuditEntity(entity string) bool {
+	switch entity {
+	case AuditEntityItem, AuditEntityCustomer, AuditEntityTransaction:
+		return true
+	}
+	return false
+}
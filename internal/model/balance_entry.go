@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+const (
+	BalanceEntryDebit      = "debit"
+	BalanceEntryCredit     = "credit"
+	BalanceEntryRefund     = "refund"
+	BalanceEntryAdjustment = "adjustment"
+)
+
+// BalanceEntry is an immutable row of the customer balance ledger.
+// Amount is signed: debits are negative, credits, refunds and positive adjustments are positive.
+//
+//swagger:model
+type BalanceEntry struct {
+	ID            int       `json:"id"`
+	CustomerID    int       `json:"customer_id"`
+	TransactionID *int      `json:"transaction_id"`
+	OrderID       *int      `json:"order_id"`
+	Kind          string    `json:"kind"`
+	Amount        Money     `json:"amount"`
+	BalanceAfter  Money     `json:"balance_after"`
+	Reason        string    `json:"reason"`
+	Operator      string    `json:"operator"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BalanceOperation is a cashier initiated deposit or withdrawal
+type BalanceOperation struct {
+	CustomerID int    `json:"customer_id"`
+	Amount     Money  `json:"amount"`
+	Reason     string `json:"reason"`
+	Operator   string `json:"operator"`
+}
+
+//swagger:model
+type LedgerReconciliation struct {
+	CustomerID    int   `json:"customer_id"`
+	Balance       Money `json:"balance"`
+	LedgerBalance Money `json:"ledger_balance"`
+	Difference    Money `json:"difference"`
+	Entries       int   `json:"entries"`
+	Reconciled    bool  `json:"reconciled"`
+}
@@ -1,13 +1,36 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 //swagger:model
 type Customer struct {
 	ID        int        `json:"id"`
+	TenantID  int        `json:"tenant_id"`
 	Name      string     `json:"customer_name"`
-	Balance   float64    `json:"balance"`
+	Balance   Money      `json:"balance"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at"`
+	// CreatedBy and UpdatedBy name the actor of the first and the last change
+	CreatedBy string `json:"created_by"`
+	UpdatedBy string `json:"updated_by"`
+}
+
+// Validate checks the fields set by clients when a customer is created or updated
+func (c Customer) Validate() error {
+	var err string
+	if c.Name == "" || len(c.Name) < 3 {
+		err += " name is invalid or shorter than 3,"
+	}
+	if c.Balance <= 0 {
+		err += " balance is invalid or equal or less than zero"
+	}
+	if len(err) > 0 {
+		return errors.New(err)
+	} else {
+		return nil
+	}
 }
@@ -0,0 +1,15 @@
+package model
+
+import "errors"
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrAlreadyVoided       = errors.New("transaction already voided")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrIllegalTransition   = errors.New("illegal transaction status transition")
+	ErrReturnExceedsSold   = errors.New("return exceeds sold quantity")
+	ErrHasReturns          = errors.New("transaction has returns")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrUsernameTaken       = errors.New("username is taken")
+	ErrTenantSlugTaken     = errors.New("tenant slug is taken")
+)
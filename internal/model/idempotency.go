@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a key sent in the Idempotency-Key header with the request it was first used for.
+// StatusCode is nil while that request runs, afterwards the key replays its response until ExpiresAt.
+type IdempotencyKey struct {
+	Actor        string    `json:"actor"`
+	Key          string    `json:"key"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	RequestHash  []byte    `json:"-"`
+	StatusCode   *int      `json:"status_code"`
+	ContentType  string    `json:"content_type"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
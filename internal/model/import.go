@@ -0,0 +1,37 @@
+package model
+
+// ImportOptions control a bulk import: DryRun rolls the import back after checking it,
+// Upsert updates the rows whose name already exists instead of rejecting them
+type ImportOptions struct {
+	DryRun bool `json:"dry_run"`
+	Upsert bool `json:"upsert"`
+}
+
+// ImportRow is a parsed row of an import file, Line is its line number in the file
+type ImportRow[T any] struct {
+	Line  int
+	Value T
+}
+
+//swagger:model
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportChange is a row written by an import, Before is nil when the row was created
+type ImportChange[T any] struct {
+	Before *T
+	After  T
+}
+
+// ImportResult reports an import, nothing is written when Errors is not empty
+//
+//swagger:model
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Errors  []ImportRowError `json:"errors"`
+}
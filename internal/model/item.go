@@ -1,15 +1,45 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 //swagger:model
 type Item struct {
 	ID        int        `json:"id"`
+	TenantID  int        `json:"tenant_id"`
 	ItemName  string     `json:"item_name"`
-	Cost      float64    `json:"cost"`
-	Price     float64    `json:"price"`
+	Cost      Money      `json:"cost"`
+	Price     Money      `json:"price"`
 	Sort      int        `json:"sort"`
+	Stock     *int       `json:"stock"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at"`
+	// CreatedBy and UpdatedBy name the actor of the first and the last change
+	CreatedBy string `json:"created_by"`
+	UpdatedBy string `json:"updated_by"`
+}
+
+// Validate checks the fields set by clients when an item is created or updated
+func (i Item) Validate() error {
+	var err string
+	if i.ItemName == "" || len(i.ItemName) < 3 {
+		err += " item name is invalid or shorter than 3,"
+	}
+	if i.Cost <= 0 {
+		err += " cost is invalid or under zero,"
+	}
+	if i.Price <= 0 {
+		err += " price is invalid or under zero,"
+	}
+	if i.Sort <= 0 {
+		err += " sort is invalid or under zero,"
+	}
+	if len(err) != 0 {
+		return errors.New(err)
+	} else {
+		return nil
+	}
 }
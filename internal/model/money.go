@@ -0,0 +1,133 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units (cents).
+//
+// Rounding rules:
+//   - amounts parsed from JSON or the database are rounded to whole cents, half away from zero
+//   - addition, subtraction and multiplication by a quantity are exact
+//   - MulDiv rounds the result to whole cents, half away from zero
+//
+// In JSON it is written as a decimal number with two fractional digits, e.g. 12.30,
+// and in postgres it is stored as NUMERIC(19, 2).
+type Money int64
+
+const moneyScale = 100
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// ParseMoney parses a decimal string like "12.3", "-0.05" or "1e2"
+func ParseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	r.Mul(r, big.NewRat(moneyScale, 1))
+	return roundRat(r)
+}
+
+// roundRat rounds r to an integer, half away from zero
+func roundRat(r *big.Rat) (Money, error) {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: %s out of range", ErrInvalidMoney, r.FloatString(2))
+	}
+	if r.Sign() < 0 {
+		return Money(-quo.Int64()), nil
+	}
+	return Money(quo.Int64()), nil
+}
+
+// Mul returns the amount multiplied by qty
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// MulDiv returns m * n / d rounded to whole cents, used for proportional amounts
+func (m Money) MulDiv(n, d int) Money {
+	if d == 0 {
+		return 0
+	}
+	r := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(n))),
+		big.NewInt(int64(d)),
+	)
+	result, err := roundRat(r)
+	if err != nil {
+		return Money(math.MaxInt64)
+	}
+	return result
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both numbers and quoted decimal strings
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner, postgres NUMERIC values arrive as decimal strings
+func (m *Money) Scan(src any) error {
+	var parsed Money
+	var err error
+	switch v := src.(type) {
+	case string:
+		parsed, err = ParseMoney(v)
+	case []byte:
+		parsed, err = ParseMoney(string(v))
+	case int64:
+		parsed = Money(v * moneyScale)
+	case nil:
+		return fmt.Errorf("%w: cannot scan NULL", ErrInvalidMoney)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements driver.Valuer
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  bool
+	}{
+		{in: "12.3", want: 1230},
+		{in: "12.30", want: 1230},
+		{in: "0", want: 0},
+		{in: " 7 ", want: 700},
+		{in: "1e2", want: 10000},
+		{in: "-0.05", want: -5},
+		{in: "-12.34", want: -1234},
+		// fractions of a cent round half away from zero
+		{in: "0.005", want: 1},
+		{in: "-0.005", want: -1},
+		{in: "0.0049", want: 0},
+		{in: "-0.0049", want: 0},
+		{in: "1.235", want: 124},
+		{in: "-1.235", want: -124},
+		{in: "1.234", want: 123},
+		{in: "", err: true},
+		{in: "abc", err: true},
+		{in: "1.2.3", err: true},
+		{in: "12,30", err: true},
+		{in: "1e30", err: true},
+		{in: "-1e30", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q): got %v, %v, want ErrInvalidMoney", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestRoundRat(t *testing.T) {
+	tests := []struct {
+		num, den int64
+		want     Money
+	}{
+		{num: 0, den: 1, want: 0},
+		{num: 4, den: 2, want: 2},
+		{num: 5, den: 2, want: 3},
+		{num: -5, den: 2, want: -3},
+		{num: 7, den: 3, want: 2},
+		{num: -7, den: 3, want: -2},
+		{num: 8, den: 3, want: 3},
+		{num: -8, den: 3, want: -3},
+		{num: 1, den: 3, want: 0},
+		{num: -1, den: 3, want: 0},
+	}
+	for _, tt := range tests {
+		got, err := roundRat(big.NewRat(tt.num, tt.den))
+		if err != nil || got != tt.want {
+			t.Errorf("roundRat(%d/%d) = %v, %v, want %d", tt.num, tt.den, got, err, tt.want)
+		}
+	}
+
+	huge := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 64))
+	if _, err := roundRat(huge); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("roundRat(2^64): got %v, want ErrInvalidMoney", err)
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		m    Money
+		n, d int
+		want Money
+	}{
+		{m: 1000, n: 1, d: 1, want: 1000},
+		{m: 1000, n: 1, d: 2, want: 500},
+		{m: 1000, n: 1, d: 3, want: 333},
+		{m: 1000, n: 2, d: 3, want: 667},
+		{m: 5, n: 1, d: 2, want: 3},
+		{m: -5, n: 1, d: 2, want: -3},
+		{m: -1000, n: 2, d: 3, want: -667},
+		{m: 1000, n: -1, d: 4, want: -250},
+		{m: 1000, n: 0, d: 3, want: 0},
+		{m: 1000, n: 1, d: 0, want: 0},
+		// the product doesn't overflow before the division
+		{m: math.MaxInt64, n: 2, d: 2, want: math.MaxInt64},
+		{m: math.MaxInt64, n: 2, d: 1, want: math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulDiv(tt.n, tt.d); got != tt.want {
+			t.Errorf("Money(%d).MulDiv(%d, %d) = %d, want %d", int64(tt.m), tt.n, tt.d, int64(got), int64(tt.want))
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: 0, want: "0.00"},
+		{m: 5, want: "0.05"},
+		{m: -5, want: "-0.05"},
+		{m: 1230, want: "12.30"},
+		{m: -1234, want: "-12.34"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.m), got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  bool
+	}{
+		{in: `12.3`, want: 1230},
+		{in: `"12.30"`, want: 1230},
+		{in: `-0.005`, want: -1},
+		{in: `null`, want: 42},
+		{in: `"abc"`, err: true},
+		{in: `true`, err: true},
+	}
+	for _, tt := range tests {
+		m := Money(42)
+		err := m.UnmarshalJSON([]byte(tt.in))
+		if tt.err {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("UnmarshalJSON(%s): got %v, want ErrInvalidMoney", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || m != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %v, %v, want %v", tt.in, m, err, tt.want)
+		}
+	}
+}
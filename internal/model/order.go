@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const OrderStatusPaid = "paid"
+
+// Order is a receipt for a basket of items, the customer is charged Total once for all lines
+//
+//swagger:model
+type Order struct {
+	ID         int         `json:"id"`
+	TenantID   int         `json:"tenant_id"`
+	CustomerID int         `json:"customer_id"`
+	Status     string      `json:"status"`
+	Total      Money       `json:"total"`
+	Lines      []OrderLine `json:"lines"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	// CreatedBy names the actor that placed the order
+	CreatedBy string `json:"created_by"`
+}
+
+//swagger:model
+type OrderLine struct {
+	ID      int   `json:"id"`
+	OrderID int   `json:"order_id"`
+	ItemID  int   `json:"item_id"`
+	Qty     int   `json:"qty"`
+	Price   Money `json:"price"`
+	Amount  Money `json:"amount"`
+}
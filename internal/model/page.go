@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Page is a cursor paginated list, NextCursor is empty on the last page
+//
+//swagger:model
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// cursor is the position after which the next page starts, clients only see it base64 encoded
+type cursor struct {
+	ID int `json:"id"`
+}
+
+// EncodeCursor returns the opaque cursor of the page starting after id
+func EncodeCursor(id int) string {
+	data, _ := json.Marshal(cursor{ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the id a page starts after, an empty cursor starts at the beginning
+func DecodeCursor(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if c.ID < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return c.ID, nil
+}
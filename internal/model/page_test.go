@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		cursor string
+		want   int
+		err    bool
+	}{
+		{name: "empty", cursor: "", want: 0},
+		{name: "zero", cursor: EncodeCursor(0), want: 0},
+		{name: "id", cursor: EncodeCursor(42), want: 42},
+		{name: "max id", cursor: EncodeCursor(math.MaxInt), want: math.MaxInt},
+		{name: "unknown fields", cursor: encode(`{"id":7,"sort":"name"}`), want: 7},
+		{name: "negative id", cursor: encode(`{"id":-1}`), err: true},
+		{name: "not base64", cursor: "!!!", err: true},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"id":1}`)), err: true},
+		{name: "not json", cursor: encode("not json"), err: true},
+		{name: "string id", cursor: encode(`{"id":"1"}`), err: true},
+		{name: "fractional id", cursor: encode(`{"id":1.5}`), err: true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeCursor(tt.cursor)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("%s: DecodeCursor(%q) = %d, %v, want ErrInvalidCursor", tt.name, tt.cursor, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DecodeCursor(%q) = %d, %v, want %d", tt.name, tt.cursor, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+const (
+	ReportGroupDay   = "day"
+	ReportGroupWeek  = "week"
+	ReportGroupMonth = "month"
+)
+
+// ReportFilter limits a report to sales created in [From, To], GroupBy is the period of the sales report.
+// Sales are paid or fulfilled transactions net of their returns and the lines of paid orders.
+type ReportFilter struct {
+	From    *time.Time `json:"from"`
+	To      *time.Time `json:"to"`
+	GroupBy string     `json:"group_by"`
+}
+
+// SalesReportRow sums the sales of a period starting at Period.
+// Cost uses the current Item.Cost, so Margin is Revenue minus what the sold units cost today.
+//
+//swagger:model
+type SalesReportRow struct {
+	Period  time.Time `json:"period"`
+	Units   int       `json:"units"`
+	Revenue Money     `json:"revenue"`
+	Cost    Money     `json:"cost"`
+	Margin  Money     `json:"margin"`
+}
+
+//swagger:model
+type ItemReportRow struct {
+	ItemID   int    `json:"item_id"`
+	ItemName string `json:"item_name"`
+	Units    int    `json:"units"`
+	Revenue  Money  `json:"revenue"`
+	Cost     Money  `json:"cost"`
+	Margin   Money  `json:"margin"`
+}
+
+//swagger:model
+type CustomerReportRow struct {
+	CustomerID   int    `json:"customer_id"`
+	CustomerName string `json:"customer_name"`
+	Units        int    `json:"units"`
+	Revenue      Money  `json:"revenue"`
+	Cost         Money  `json:"cost"`
+	Margin       Money  `json:"margin"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// TransactionReturn gives back part of the units of a charged transaction,
+// Amount is the share of the transaction amount credited to the customer.
+//
+//swagger:model
+type TransactionReturn struct {
+	ID            int       `json:"id"`
+	TransactionID int       `json:"transaction_id"`
+	Qty           int       `json:"qty"`
+	Amount        Money     `json:"amount"`
+	Reason        string    `json:"reason"`
+	Operator      string    `json:"operator"`
+	CreatedAt     time.Time `json:"created_at"`
+}
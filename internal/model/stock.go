@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	StockMovementReceipt    = "receipt"
+	StockMovementAdjustment = "adjustment"
+	StockMovementSale       = "sale"
+	StockMovementVoid       = "void"
+	StockMovementReturn     = "return"
+)
+
+// StockMovement is an immutable row of the item stock history, Qty is signed.
+//
+//swagger:model
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ItemID        int       `json:"item_id"`
+	TransactionID *int      `json:"transaction_id"`
+	OrderID       *int      `json:"order_id"`
+	Kind          string    `json:"kind"`
+	Qty           int       `json:"qty"`
+	StockAfter    int       `json:"stock_after"`
+	Reason        string    `json:"reason"`
+	Operator      string    `json:"operator"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StockOperation is a stock receipt or a manual adjustment
+type StockOperation struct {
+	ItemID   int    `json:"item_id"`
+	Qty      int    `json:"qty"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Tenant is a shop, items, customers and transactions belong to exactly one
+//
+//swagger:model
+type Tenant struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -5,20 +5,70 @@ import "time"
 //swagger:model
 type Transaction struct {
 	ID         int        `json:"id"`
+	TenantID   int        `json:"tenant_id"`
 	CustomerID int        `json:"customer_id"`
 	ItemID     int        `json:"item_id"`
 	Qty        int        `json:"qty"`
-	Price      float64    `json:"price"`
-	Amount     float64    `json:"amount"`
+	Price      Money      `json:"price"`
+	Amount     Money      `json:"amount"`
+	Status     string     `json:"status"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 	DeletedAt  *time.Time `json:"deleted_at"`
+	VoidedAt   *time.Time `json:"voided_at"`
+	VoidReason *string    `json:"void_reason"`
+	// PriceOverrideReason is set when Price was supplied by the client instead of the item catalog
+	PriceOverrideReason *string    `json:"price_override_reason"`
+	PaidAt              *time.Time `json:"paid_at"`
+	FulfilledAt         *time.Time `json:"fulfilled_at"`
+	CancelledAt         *time.Time `json:"cancelled_at"`
+	RefundedAt          *time.Time `json:"refunded_at"`
+	// ReturnedQty and ReturnedAmount sum up the partial returns of the transaction
+	ReturnedQty    int   `json:"returned_qty"`
+	ReturnedAmount Money `json:"returned_amount"`
+	// CreatedBy and UpdatedBy name the actor of the first and the last change
+	CreatedBy string `json:"created_by"`
+	UpdatedBy string `json:"updated_by"`
 }
 
+// TransactionFilter searches transaction views, zero fields are not filtered on.
+// Names match partially and case-insensitively, ranges include their bounds.
 type TransactionFilter struct {
-	ID           int    `json:"id"`
-	CustomerName string `json:"customer_name"`
-	ItemName     string `json:"item_name"`
+	ID           int               `json:"id"`
+	CustomerName string            `json:"customer_name"`
+	ItemName     string            `json:"item_name"`
+	CustomerIDs  []int             `json:"customer_ids"`
+	ItemIDs      []int             `json:"item_ids"`
+	Statuses     []string          `json:"statuses"`
+	CreatedFrom  *time.Time        `json:"created_from"`
+	CreatedTo    *time.Time        `json:"created_to"`
+	AmountMin    *Money            `json:"amount_min"`
+	AmountMax    *Money            `json:"amount_max"`
+	QtyMin       *int              `json:"qty_min"`
+	QtyMax       *int              `json:"qty_max"`
+	Sort         []TransactionSort `json:"sort"`
+	Limit        int               `json:"limit"`
+	Offset       int               `json:"offset"`
+}
+
+// TransactionSort orders the search by Field, one of TransactionSortFields
+type TransactionSort struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+var TransactionSortFields = []string{
+	"id", "created_at", "updated_at", "amount", "price", "qty", "status", "customer_name", "item_name",
+}
+
+// TransactionViewPage is a page of a transaction search, Total counts all matching rows
+//
+//swagger:model
+type TransactionViewPage struct {
+	Items  []TransactionView `json:"items"`
+	Total  int               `json:"total"`
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
 }
 
 //swagger:model
@@ -29,8 +79,9 @@ type TransactionView struct {
 	ItemID       int        `json:"item_id"`
 	ItemName     string     `json:"item_name"`
 	Qty          int        `json:"qty"`
-	Price        float64    `json:"price"`
-	Amount       float64    `json:"amount"`
+	Price        Money      `json:"price"`
+	Amount       Money      `json:"amount"`
+	Status       string     `json:"status"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 	DeletedAt    *time.Time `json:"deleted_at"`
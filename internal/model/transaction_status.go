@@ -0,0 +1,42 @@
+package model
+
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusPaid      = "paid"
+	TransactionStatusFulfilled = "fulfilled"
+	TransactionStatusCancelled = "cancelled"
+	TransactionStatusRefunded  = "refunded"
+)
+
+// transactionTransitions lists the statuses a transaction can move to from each status,
+// cancelled and refunded are final
+var transactionTransitions = map[string][]string{
+	TransactionStatusPending:   {TransactionStatusPaid, TransactionStatusCancelled},
+	TransactionStatusPaid:      {TransactionStatusFulfilled, TransactionStatusCancelled, TransactionStatusRefunded},
+	TransactionStatusFulfilled: {TransactionStatusRefunded},
+}
+
+// CanTransition reports whether a transaction in status from may move to status to
+func CanTransition(from, to string) bool {
+	for _, status := range transactionTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCharged reports whether the customer has been charged for a transaction in status
+func IsCharged(status string) bool {
+	return status == TransactionStatusPaid || status == TransactionStatusFulfilled
+}
+
+// IsTransactionStatus reports whether status is one of the known transaction statuses
+func IsTransactionStatus(status string) bool {
+	switch status {
+	case TransactionStatusPending, TransactionStatusPaid, TransactionStatusFulfilled,
+		TransactionStatusCancelled, TransactionStatusRefunded:
+		return true
+	}
+	return false
+}
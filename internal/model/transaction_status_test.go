@@ -0,0 +1,63 @@
+package model
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	statuses := []string{
+		TransactionStatusPending,
+		TransactionStatusPaid,
+		TransactionStatusFulfilled,
+		TransactionStatusCancelled,
+		TransactionStatusRefunded,
+	}
+	allowed := map[[2]string]bool{
+		{TransactionStatusPending, TransactionStatusPaid}:       true,
+		{TransactionStatusPending, TransactionStatusCancelled}:  true,
+		{TransactionStatusPaid, TransactionStatusFulfilled}:     true,
+		{TransactionStatusPaid, TransactionStatusCancelled}:     true,
+		{TransactionStatusPaid, TransactionStatusRefunded}:      true,
+		{TransactionStatusFulfilled, TransactionStatusRefunded}: true,
+	}
+	// every pair of known statuses, a status never moves to itself
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	unknown := []struct{ from, to string }{
+		{"", TransactionStatusPaid},
+		{TransactionStatusPending, ""},
+		{"shipped", TransactionStatusPaid},
+		{TransactionStatusPaid, "shipped"},
+		{"PENDING", TransactionStatusPaid},
+		{TransactionStatusPending, "PAID"},
+	}
+	for _, tt := range unknown {
+		if CanTransition(tt.from, tt.to) {
+			t.Errorf("CanTransition(%q, %q) = true, want false", tt.from, tt.to)
+		}
+	}
+}
+
+func TestIsCharged(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{TransactionStatusPending, false},
+		{TransactionStatusPaid, true},
+		{TransactionStatusFulfilled, true},
+		{TransactionStatusCancelled, false},
+		{TransactionStatusRefunded, false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsCharged(tt.status); got != tt.want {
+			t.Errorf("IsCharged(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+const (
+	RoleAuditor = "auditor"
+	RoleCashier = "cashier"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+)
+
+var (
+	auditorPermissions = []Permission{
+		PermissionItemRead,
+		PermissionCustomerRead,
+		PermissionTransactionRead,
+		PermissionReportRead,
+		PermissionAuditRead,
+	}
+	cashierPermissions = append(
+		append([]Permission{}, auditorPermissions...),
+		PermissionTransactionWrite,
+		PermissionCustomerBalance,
+	)
+	managerPermissions = append(
+		append([]Permission{}, cashierPermissions...),
+		PermissionItemWrite,
+		PermissionCustomerWrite,
+		PermissionTransactionVoid,
+		PermissionPriceOverride,
+	)
+	adminPermissions = append(
+		append([]Permission{}, managerPermissions...),
+		PermissionAPIKeyManage,
+		PermissionUserManage,
+	)
+)
+
+// RolePermissions maps the roles to what they allow: auditors only read, cashiers sell and
+// move balances, managers also change the catalog and customers and undo sales,
+// admins also manage users and api keys
+var RolePermissions = map[string][]Permission{
+	RoleAuditor: auditorPermissions,
+	RoleCashier: cashierPermissions,
+	RoleManager: managerPermissions,
+	RoleAdmin:   adminPermissions,
+}
+
+//swagger:model
+type User struct {
+	ID         int        `json:"id"`
+	TenantID   int        `json:"tenant_id"`
+	Username   string     `json:"username"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+// UserUpdate changes the fields that are not nil
+type UserUpdate struct {
+	ID       int     `json:"id"`
+	Role     *string `json:"role"`
+	Password *string `json:"password"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// Token is a signed JWT issued on login
+//
+//swagger:model
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Actor returns the actor of requests made by the user
+func (u User) Actor() Actor {
+	return Actor{Name: "user:" + u.Username, TenantID: u.TenantID, Permissions: RolePermissions[u.Role]}
+}
+
+func IsRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor that performs the request
+func WithActor(ctx context.Context, actor model.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of the request or an anonymous actor without permissions
+func ActorFromContext(ctx context.Context) model.Actor {
+	actor, _ := ctx.Value(actorKey{}).(model.Actor)
+	return actor
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the id of the request, it is written to the audit log
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the id of the request or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
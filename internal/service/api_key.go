@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+const (
+	apiKeyPrefix    = "xk_"
+	apiKeyBytes     = 32
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+type APIKeyService struct {
+	t APIKeyRepository
+}
+
+func NewAPIKeyService(t APIKeyRepository) *APIKeyService {
+	return &APIKeyService{t: t}
+}
+
+// hashAPIKey is what is stored and looked up, keys are random so a plain sha256 is enough
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// Create generates a key, the returned secret is never stored and can't be read back
+func (s *APIKeyService) Create(ctx context.Context, key model.APIKey) (model.NewAPIKey, Status) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Create")
+	defer span.End()
+	var status Status
+	if strings.TrimSpace(key.Name) == "" {
+		return model.NewAPIKey{}, status.withError(
+			"APIKeyService - Create - key.Name:%w", nil, "name is required", http.StatusBadRequest,
+		)
+	}
+	if !model.IsAPIKeyScope(key.Scope) {
+		return model.NewAPIKey{}, status.withError(
+			"APIKeyService - Create - model.IsAPIKeyScope:%w",
+			nil,
+			"scope must be read_only, cashier or admin",
+			http.StatusBadRequest,
+		)
+	}
+	secret := make([]byte, apiKeyBytes)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return model.NewAPIKey{}, status.withError(
+			"APIKeyService - Create - rand.Read:%w", err, "couldn't generate api key", http.StatusInternalServerError,
+		)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key.Prefix = plain[:apiKeyPrefixLen]
+	key, err = s.t.Create(ctx, key, hashAPIKey(plain))
+	if err != nil {
+		return model.NewAPIKey{}, status.withError(
+			"APIKeyService - Create - s.t.Create:%w", err, "couldn't create api key", http.StatusInternalServerError,
+		)
+	}
+	return model.NewAPIKey{APIKey: key, Key: plain}, status.success("api key created", http.StatusCreated)
+}
+
+// Authenticate returns the actor of an active key
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (model.Actor, Status) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+	var status Status
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return model.Actor{}, status.withError(
+			"APIKeyService - Authenticate - strings.HasPrefix:%w", nil, "api key is invalid", http.StatusUnauthorized,
+		)
+	}
+	key, err := s.t.UseByHash(ctx, hashAPIKey(plain))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Actor{}, status.withError(
+				"APIKeyService - Authenticate - s.t.UseByHash:%w", err, "api key is invalid", http.StatusUnauthorized,
+			)
+		}
+		return model.Actor{}, status.withError(
+			"APIKeyService - Authenticate - s.t.UseByHash:%w", err, "couldn't check api key", http.StatusInternalServerError,
+		)
+	}
+	return key.Actor(), status.success("api key is valid", http.StatusOK)
+}
+
+func (s *APIKeyService) GetAll(ctx context.Context) ([]model.APIKey, Status) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.GetAll")
+	defer span.End()
+	var status Status
+	keys, err := s.t.GetAll(ctx)
+	if err != nil {
+		return nil, status.withError(
+			"APIKeyService - GetAll - s.t.GetAll:%w", err, "couldn't get api keys", http.StatusInternalServerError,
+		)
+	}
+	return keys, status.success("api keys found", http.StatusOK)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int) (model.APIKey, Status) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Revoke")
+	defer span.End()
+	var status Status
+	key, err := s.t.Revoke(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return key, status.withError(
+				"APIKeyService - Revoke - s.t.Revoke:%w", err, "api key does not exist or is revoked", http.StatusNotFound,
+			)
+		}
+		return key, status.withError(
+			"APIKeyService - Revoke - s.t.Revoke:%w", err, "couldn't revoke api key", http.StatusInternalServerError,
+		)
+	}
+	return key, status.success("api key revoked", http.StatusOK)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type AuditService struct {
+	t  AuditRepository
+	tx TxRepository
+}
+
+func NewAuditService(t AuditRepository, tx TxRepository) *AuditService {
+	return &AuditService{t: t, tx: tx}
+}
+
+// change runs apply in one database transaction with the audit entries it records,
+// so a change is never stored without its entry nor an entry without its change
+func (s *AuditService) change(ctx context.Context, apply func(ctx context.Context) error) error {
+	return s.tx.InTx(ctx, apply)
+}
+
+// record appends a change to the audit log, before and after are nil for a creation and a deletion.
+// It is called inside change with its ctx, an entry that can't be written rolls the change back;
+// the failure is counted by xiaoma_audit_failures_total.
+func (s *AuditService) record(ctx context.Context, entity, action string, id int, before, after any) error {
+	ctx, span := tracing.Start(ctx, "AuditService.record")
+	defer span.End()
+	entry := model.AuditEntry{
+		Actor:     ActorFromContext(ctx).Name,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		RequestID: RequestIDFromContext(ctx),
+	}
+	var err error
+	if entry.Before, err = snapshot(before); err == nil {
+		entry.After, err = snapshot(after)
+	}
+	if err == nil {
+		err = s.t.Create(ctx, entry)
+	}
+	if err != nil {
+		metrics.AuditFailed(entity)
+		span.SetStatus(codes.Error, "audit log entry can't be written")
+		return fmt.Errorf("AuditService - record - %s %s %d: %w", action, entity, id, err)
+	}
+	return nil
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func validateAuditFilter(filter *model.AuditFilter) error {
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		return fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	if filter.Offset < 0 {
+		return errors.New("offset can't be negative")
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return errors.New("created_from is after created_to")
+	}
+	if filter.Action != "" && !model.IsAuditAction(filter.Action) {
+		return fmt.Errorf("unknown action %q", filter.Action)
+	}
+	if filter.Entity != "" && !model.IsAuditEntity(filter.Entity) {
+		return fmt.Errorf("unknown entity %q", filter.Entity)
+	}
+	return nil
+}
+
+// GetByFilter returns a page of the audit log, newest entries first
+func (s *AuditService) GetByFilter(ctx context.Context, filter model.AuditFilter) (model.AuditPage, Status) {
+	ctx, span := tracing.Start(ctx, "AuditService.GetByFilter")
+	defer span.End()
+	var status Status
+	if err := validateAuditFilter(&filter); err != nil {
+		return model.AuditPage{}, status.withError(
+			"AuditService - GetByFilter - validateAuditFilter:%w", err, err.Error(), http.StatusBadRequest,
+		)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+	page, err := s.t.GetByFilter(ctx, filter)
+	if err != nil {
+		return page, status.withError(
+			"AuditService - GetByFilter - s.t.GetByFilter:%w", err, "couldn't get the audit log", http.StatusInternalServerError,
+		)
+	}
+	return page, status.success("audit log retrieved", http.StatusOK)
+}
@@ -2,20 +2,64 @@ package service
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
 )
 
 type CustomerService struct {
-	t CustomerRepository
+	t     CustomerRepository
+	audit *AuditService
 }
 
-func NewCustomerService(t CustomerRepository) *CustomerService {
-	return &CustomerService{t: t}
+func NewCustomerService(t CustomerRepository, audit *AuditService) *CustomerService {
+	return &CustomerService{t: t, audit: audit}
+}
+
+// getForChange returns the stored customer for the before snapshot of a change
+func (s *CustomerService) getForChange(ctx context.Context, id int, caller string) (model.Customer, Status) {
+	var status Status
+	customer, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return customer, status.withError(caller+" - s.t.GetByID:%w", err, "customer does not exist", http.StatusNotFound)
+		}
+		return customer, status.withError(
+			caller+" - s.t.GetByID:%w", err, "couldn't get customer", http.StatusInternalServerError,
+		)
+	}
+	return customer, status.success("customer retrieved", http.StatusOK)
+}
+
+// changeBalance runs a deposit or a withdrawal and audits it as an update of the customer
+func (s *CustomerService) changeBalance(
+	ctx context.Context,
+	before model.Customer,
+	apply func(ctx context.Context) (model.Money, error),
+) (model.Money, error) {
+	var balance model.Money
+	err := s.audit.change(ctx, func(ctx context.Context) error {
+		var err error
+		balance, err = apply(ctx)
+		if err != nil {
+			return err
+		}
+		after, err := s.t.GetByID(ctx, before.ID)
+		if err != nil {
+			return err
+		}
+		return s.audit.record(ctx, model.AuditEntityCustomer, model.AuditActionUpdate, before.ID, before, after)
+	})
+	return balance, err
 }
 
 func (s *CustomerService) Create(ctx context.Context, customer model.Customer) (int, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Create")
+	defer span.End()
 	var status Status
 	ID, err := s.t.IDByName(ctx, customer.Name)
 	if err != nil {
@@ -29,16 +73,30 @@ func (s *CustomerService) Create(ctx context.Context, customer model.Customer) (
 		)
 	}
 
-	id, err := s.t.Create(ctx, customer)
+	customer.CreatedBy = ActorFromContext(ctx).Name
+	customer.UpdatedBy = customer.CreatedBy
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		id, err := s.t.Create(ctx, customer)
+		if err != nil {
+			return err
+		}
+		customer.ID = id
+		if created, err := s.t.GetByID(ctx, id); err == nil {
+			customer = created
+		}
+		return s.audit.record(ctx, model.AuditEntityCustomer, model.AuditActionCreate, id, nil, customer)
+	})
 	if err != nil {
 		return 0, status.withError(
 			"CustomerService - Create - s.t.Create:%w", err, "error with customer creation", http.StatusInternalServerError,
 		)
 	}
-	return id, status.success("customer succesfully created", http.StatusCreated)
+	return customer.ID, status.success("customer succesfully created", http.StatusCreated)
 }
 
 func (s *CustomerService) GetByID(ctx context.Context, id int) (model.Customer, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetByID")
+	defer span.End()
 	var status Status
 	var customer model.Customer
 	exist, err := s.t.IDExists(ctx, id)
@@ -59,6 +117,8 @@ func (s *CustomerService) GetByID(ctx context.Context, id int) (model.Customer,
 }
 
 func (s *CustomerService) GetAll(ctx context.Context, limit, offset int) ([]model.Customer, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetAll")
+	defer span.End()
 	var status Status
 	var customers []model.Customer
 	customers, err := s.t.GetAll(ctx, limit, offset)
@@ -70,15 +130,42 @@ func (s *CustomerService) GetAll(ctx context.Context, limit, offset int) ([]mode
 	return customers, status.success("customers retrieved", http.StatusOK)
 }
 
-func (s *CustomerService) Update(ctx context.Context, customer model.Customer) (model.Customer, Status) {
+func (s *CustomerService) GetPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) (model.Page[model.Customer], Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.GetPage")
+	defer span.End()
 	var status Status
-	exist, err := s.t.IDExists(ctx, customer.ID)
+	afterID, limit, err := pageQuery(cursor, limit)
 	if err != nil {
-		if !exist {
-			return customer, status.withError(
-				"CustomerService - Update -  s.t.IDExists:%w", err, "customer does not exist", http.StatusNotFound,
-			)
-		}
+		return model.Page[model.Customer]{}, status.withError(
+			"CustomerService - GetPage - pageQuery:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	customers, err := s.t.GetAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return model.Page[model.Customer]{}, status.withError(
+			"CustomerService - GetPage - s.t.GetAfter:%w",
+			err,
+			"couldn't get all customers",
+			http.StatusInternalServerError,
+		)
+	}
+	page := newPage(customers, limit, func(customer model.Customer) int { return customer.ID })
+	return page, status.success("customers retrieved", http.StatusOK)
+}
+
+func (s *CustomerService) Update(ctx context.Context, customer model.Customer) (model.Customer, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Update")
+	defer span.End()
+	before, status := s.getForChange(ctx, customer.ID, "CustomerService - Update")
+	if !status.Ok() {
+		return customer, status
 	}
 	ID, err := s.t.IDByName(ctx, customer.Name)
 	if err != nil {
@@ -93,9 +180,17 @@ func (s *CustomerService) Update(ctx context.Context, customer model.Customer) (
 		)
 	}
 
-	customer, err = s.t.Update(ctx, customer)
+	customer.UpdatedBy = ActorFromContext(ctx).Name
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		updated, err := s.t.Update(ctx, customer)
+		if err != nil {
+			return err
+		}
+		customer = updated
+		return s.audit.record(ctx, model.AuditEntityCustomer, model.AuditActionUpdate, customer.ID, before, customer)
+	})
 	if err != nil {
-		return customer, status.withError(
+		return model.Customer{}, status.withError(
 			"CustomerService - Update - s.t.Update:%w", err, "couldn't update customer", http.StatusInternalServerError,
 		)
 	}
@@ -103,8 +198,18 @@ func (s *CustomerService) Update(ctx context.Context, customer model.Customer) (
 }
 
 func (s *CustomerService) Delete(ctx context.Context, id int) Status {
-	var status Status
-	err := s.t.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "CustomerService.Delete")
+	defer span.End()
+	before, status := s.getForChange(ctx, id, "CustomerService - Delete")
+	if !status.Ok() {
+		return status
+	}
+	err := s.audit.change(ctx, func(ctx context.Context) error {
+		if err := s.t.Delete(ctx, id, ActorFromContext(ctx).Name); err != nil {
+			return err
+		}
+		return s.audit.record(ctx, model.AuditEntityCustomer, model.AuditActionDelete, id, before, nil)
+	})
 	if err != nil {
 		return status.withError(
 			"CustomerService - Delete - s.t.Delete:%w", err, "couldn't delete customer", http.StatusInternalServerError,
@@ -112,3 +217,52 @@ func (s *CustomerService) Delete(ctx context.Context, id int) Status {
 	}
 	return status.success("customer deleted", http.StatusOK)
 }
+
+func (s *CustomerService) Deposit(ctx context.Context, operation model.BalanceOperation) (model.Money, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Deposit")
+	defer span.End()
+	operation.Operator = ActorFromContext(ctx).Name
+	before, status := s.getForChange(ctx, operation.CustomerID, "CustomerService - Deposit")
+	if !status.Ok() {
+		return 0, status
+	}
+	balance, err := s.changeBalance(ctx, before, func(ctx context.Context) (model.Money, error) {
+		return s.t.Deposit(ctx, operation)
+	})
+	if err != nil {
+		return 0, status.withError(
+			"CustomerService - Deposit - s.t.Deposit:%w", err, "couldn't deposit to customer balance", http.StatusInternalServerError,
+		)
+	}
+	return balance, status.success("deposit completed", http.StatusOK)
+}
+
+func (s *CustomerService) Withdraw(ctx context.Context, operation model.BalanceOperation) (model.Money, Status) {
+	ctx, span := tracing.Start(ctx, "CustomerService.Withdraw")
+	defer span.End()
+	operation.Operator = ActorFromContext(ctx).Name
+	before, status := s.getForChange(ctx, operation.CustomerID, "CustomerService - Withdraw")
+	if !status.Ok() {
+		return 0, status
+	}
+	balance, err := s.changeBalance(ctx, before, func(ctx context.Context) (model.Money, error) {
+		return s.t.Withdraw(ctx, operation)
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrInsufficientBalance) {
+			metrics.BalanceCheckFailed(metrics.OperationWithdraw)
+			return 0, status.withError(
+				"CustomerService - Withdraw - s.t.Withdraw:%w", err, "customer balance is not enough", http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, status.withError(
+				"CustomerService - Withdraw - s.t.Withdraw:%w", err, "customer id does not exist", http.StatusNotFound,
+			)
+		}
+		return 0, status.withError(
+			"CustomerService - Withdraw - s.t.Withdraw:%w", err, "couldn't withdraw from customer balance", http.StatusInternalServerError,
+		)
+	}
+	return balance, status.success("withdrawal completed", http.StatusOK)
+}
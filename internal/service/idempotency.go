@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyService struct {
+	t   IdempotencyRepository
+	ttl time.Duration
+}
+
+func NewIdempotencyService(t IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{t: t, ttl: ttl}
+}
+
+// requestHash tells requests apart, a key may only be reused with the same method, url and body
+func requestHash(method, url string, body []byte) []byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, url)
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Begin claims the key of the actor for the request. The returned key has a StatusCode when it was
+// used before and its response has to be replayed, otherwise the request runs and must be finished
+// with Complete or Release.
+func (s *IdempotencyService) Begin(
+	ctx context.Context,
+	key, method, url string,
+	body []byte,
+) (model.IdempotencyKey, Status) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+	var status Status
+	if len(key) > maxIdempotencyKeyLength {
+		return model.IdempotencyKey{}, status.withError(
+			"IdempotencyService - Begin - len:%w",
+			nil,
+			fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength),
+			http.StatusBadRequest,
+		)
+	}
+	record := model.IdempotencyKey{
+		Actor:       ActorFromContext(ctx).Name,
+		Key:         key,
+		Method:      method,
+		Path:        url,
+		RequestHash: requestHash(method, url, body),
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	stored, claimed, err := s.t.Claim(ctx, record)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.IdempotencyKey{}, status.withError(
+				"IdempotencyService - Begin - s.t.Claim:%w",
+				err,
+				"a request with this Idempotency-Key is in progress",
+				http.StatusConflict,
+			)
+		}
+		return model.IdempotencyKey{}, status.withError(
+			"IdempotencyService - Begin - s.t.Claim:%w", err, "couldn't check Idempotency-Key", http.StatusInternalServerError,
+		)
+	}
+	if claimed {
+		return stored, status.success("idempotency key claimed", http.StatusOK)
+	}
+	if string(stored.RequestHash) != string(record.RequestHash) {
+		return model.IdempotencyKey{}, status.withError(
+			"IdempotencyService - Begin - stored.RequestHash:%w",
+			nil,
+			"Idempotency-Key was already used with a different request",
+			http.StatusUnprocessableEntity,
+		)
+	}
+	if stored.StatusCode == nil {
+		return model.IdempotencyKey{}, status.withError(
+			"IdempotencyService - Begin - stored.StatusCode:%w",
+			nil,
+			"a request with this Idempotency-Key is in progress",
+			http.StatusConflict,
+		)
+	}
+	return stored, status.success("idempotency key replayed", http.StatusOK)
+}
+
+// Complete stores the response of a request that claimed its key
+func (s *IdempotencyService) Complete(ctx context.Context, record model.IdempotencyKey) Status {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Complete")
+	defer span.End()
+	var status Status
+	err := s.t.Complete(ctx, record)
+	if err != nil {
+		return status.withError(
+			"IdempotencyService - Complete - s.t.Complete:%w", err, "couldn't store the response", http.StatusInternalServerError,
+		)
+	}
+	return status.success("idempotency key completed", http.StatusOK)
+}
+
+// Release forgets the key of a failed request so the client can retry it
+func (s *IdempotencyService) Release(ctx context.Context, record model.IdempotencyKey) Status {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Release")
+	defer span.End()
+	var status Status
+	err := s.t.Release(ctx, record.Actor, record.Key)
+	if err != nil {
+		return status.withError(
+			"IdempotencyService - Release - s.t.Release:%w", err, "couldn't release Idempotency-Key", http.StatusInternalServerError,
+		)
+	}
+	return status.success("idempotency key released", http.StatusOK)
+}
+
+// Purge deletes the expired keys
+func (s *IdempotencyService) Purge(ctx context.Context) (int64, Status) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Purge")
+	defer span.End()
+	var status Status
+	purged, err := s.t.Purge(ctx)
+	if err != nil {
+		return 0, status.withError(
+			"IdempotencyService - Purge - s.t.Purge:%w", err, "couldn't purge expired idempotency keys", http.StatusInternalServerError,
+		)
+	}
+	return purged, status.success("expired idempotency keys purged", http.StatusOK)
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type ImportService struct {
+	t     ImportRepository
+	audit *AuditService
+}
+
+func NewImportService(t ImportRepository, audit *AuditService) *ImportService {
+	return &ImportService{t: t, audit: audit}
+}
+
+var (
+	itemImportColumns     = []string{"item_name", "cost", "price", "sort"}
+	customerImportColumns = []string{"customer_name", "balance"}
+)
+
+// ImportItems imports a CSV file with the columns item_name, cost, price and sort in any order
+func (s *ImportService) ImportItems(
+	ctx context.Context,
+	r io.Reader,
+	options model.ImportOptions,
+) (model.ImportResult, Status) {
+	ctx, span := tracing.Start(ctx, "ImportService.ImportItems")
+	defer span.End()
+	var status Status
+	actor := ActorFromContext(ctx).Name
+	rows, rowErrors, err := parseImport(r, itemImportColumns,
+		func(record *importRecord) model.Item {
+			return model.Item{
+				ItemName:  record.str("item_name"),
+				Cost:      record.money("cost"),
+				Price:     record.money("price"),
+				Sort:      record.int("sort"),
+				CreatedBy: actor,
+				UpdatedBy: actor,
+			}
+		},
+		func(item model.Item) string { return item.ItemName },
+	)
+	if err != nil {
+		return model.ImportResult{}, status.withError(
+			"ImportService - ImportItems - parseImport:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	if len(rowErrors) > 0 {
+		return invalidImport(len(rows)+len(rowErrors), rowErrors, options, "ImportService - ImportItems")
+	}
+	var result model.ImportResult
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		var changes []model.ImportChange[model.Item]
+		result, changes, err = s.t.ImportItems(ctx, rows, options)
+		if err != nil {
+			return err
+		}
+		return recordImport(ctx, s.audit, model.AuditEntityItem, changes, func(item model.Item) int { return item.ID })
+	})
+	if err != nil {
+		return result, status.withError(
+			"ImportService - ImportItems - s.t.ImportItems:%w",
+			err,
+			"couldn't import items",
+			http.StatusInternalServerError,
+		)
+	}
+	return importStatus(result, "ImportService - ImportItems", "items imported")
+}
+
+// ImportCustomers imports a CSV file with the columns customer_name and balance in any order
+func (s *ImportService) ImportCustomers(
+	ctx context.Context,
+	r io.Reader,
+	options model.ImportOptions,
+) (model.ImportResult, Status) {
+	ctx, span := tracing.Start(ctx, "ImportService.ImportCustomers")
+	defer span.End()
+	var status Status
+	actor := ActorFromContext(ctx).Name
+	rows, rowErrors, err := parseImport(r, customerImportColumns,
+		func(record *importRecord) model.Customer {
+			return model.Customer{
+				Name:      record.str("customer_name"),
+				Balance:   record.money("balance"),
+				CreatedBy: actor,
+				UpdatedBy: actor,
+			}
+		},
+		func(customer model.Customer) string { return customer.Name },
+	)
+	if err != nil {
+		return model.ImportResult{}, status.withError(
+			"ImportService - ImportCustomers - parseImport:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	if len(rowErrors) > 0 {
+		return invalidImport(len(rows)+len(rowErrors), rowErrors, options, "ImportService - ImportCustomers")
+	}
+	var result model.ImportResult
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		var changes []model.ImportChange[model.Customer]
+		result, changes, err = s.t.ImportCustomers(ctx, rows, options)
+		if err != nil {
+			return err
+		}
+		return recordImport(
+			ctx, s.audit, model.AuditEntityCustomer, changes, func(customer model.Customer) int { return customer.ID },
+		)
+	})
+	if err != nil {
+		return result, status.withError(
+			"ImportService - ImportCustomers - s.t.ImportCustomers:%w",
+			err,
+			"couldn't import customers",
+			http.StatusInternalServerError,
+		)
+	}
+	return importStatus(result, "ImportService - ImportCustomers", "customers imported")
+}
+
+// recordImport audits every row written by an import as a creation or an update
+func recordImport[T any](
+	ctx context.Context,
+	audit *AuditService,
+	entity string,
+	changes []model.ImportChange[T],
+	id func(T) int,
+) error {
+	for _, change := range changes {
+		if change.Before == nil {
+			if err := audit.record(ctx, entity, model.AuditActionCreate, id(change.After), nil, change.After); err != nil {
+				return err
+			}
+			continue
+		}
+		err := audit.record(ctx, entity, model.AuditActionUpdate, id(change.After), *change.Before, change.After)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidImport reports an import rejected before it reached the database
+func invalidImport(
+	rows int,
+	rowErrors []model.ImportRowError,
+	options model.ImportOptions,
+	caller string,
+) (model.ImportResult, Status) {
+	result := model.ImportResult{DryRun: options.DryRun, Rows: rows, Errors: rowErrors}
+	return importStatus(result, caller, "")
+}
+
+// importStatus fails with 422 when some rows were rejected, the result lists them
+func importStatus(result model.ImportResult, caller, msg string) (model.ImportResult, Status) {
+	var status Status
+	if len(result.Errors) > 0 {
+		return result, status.withError(
+			caller+":%w",
+			fmt.Errorf("%d invalid rows", len(result.Errors)),
+			"import has invalid rows, nothing was written",
+			http.StatusUnprocessableEntity,
+		)
+	}
+	if result.DryRun {
+		msg += ", dry run rolled back"
+	}
+	return result, status.success(msg, http.StatusOK)
+}
+
+// importRecord reads typed fields of a CSV record and collects their errors
+type importRecord struct {
+	values map[string]string
+	errs   []string
+}
+
+func (r *importRecord) str(column string) string {
+	return r.values[column]
+}
+
+func (r *importRecord) money(column string) model.Money {
+	m, err := model.ParseMoney(r.values[column])
+	if err != nil {
+		r.errs = append(r.errs, column+" is invalid amount")
+	}
+	return m
+}
+
+func (r *importRecord) int(column string) int {
+	n, err := strconv.Atoi(r.values[column])
+	if err != nil {
+		r.errs = append(r.errs, column+" is invalid integer")
+	}
+	return n
+}
+
+// parseImport reads a CSV file with a header row and turns every record into a value with parse.
+// Rows that don't parse, don't pass Validate or repeat the name of an earlier row are returned as row errors,
+// a malformed file or a missing column is an error.
+func parseImport[T interface{ Validate() error }](
+	r io.Reader,
+	columns []string,
+	parse func(record *importRecord) T,
+	name func(T) string,
+) ([]model.ImportRow[T], []model.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	// short rows are reported by validation instead of failing the whole file
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("file is not valid CSV: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range columns {
+		if _, ok := index[column]; !ok {
+			return nil, nil, fmt.Errorf("column %s is missing", column)
+		}
+	}
+
+	var rows []model.ImportRow[T]
+	var rowErrors []model.ImportRowError
+	lines := make(map[string]int)
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("file is not valid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		record := importRecord{values: make(map[string]string, len(columns))}
+		for _, column := range columns {
+			if i := index[column]; i < len(values) {
+				record.values[column] = strings.TrimSpace(values[i])
+			}
+		}
+		value := parse(&record)
+		if err := value.Validate(); err != nil {
+			record.errs = append(record.errs, strings.Trim(err.Error(), " ,"))
+		}
+		if previous, ok := lines[name(value)]; ok {
+			record.errs = append(record.errs, fmt.Sprintf("name repeats line %d", previous))
+		} else {
+			lines[name(value)] = line
+		}
+		if len(record.errs) > 0 {
+			rowErrors = append(rowErrors, model.ImportRowError{Line: line, Error: strings.Join(record.errs, ", ")})
+			continue
+		}
+		rows = append(rows, model.ImportRow[T]{Line: line, Value: value})
+	}
+	return rows, rowErrors, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+// parseItems parses an item import the way ImportItems does
+func parseItems(file string) ([]model.ImportRow[model.Item], []model.ImportRowError, error) {
+	return parseImport(strings.NewReader(file), itemImportColumns,
+		func(record *importRecord) model.Item {
+			return model.Item{
+				ItemName: record.str("item_name"),
+				Cost:     record.money("cost"),
+				Price:    record.money("price"),
+				Sort:     record.int("sort"),
+			}
+		},
+		func(item model.Item) string { return item.ItemName },
+	)
+}
+
+func TestParseImport(t *testing.T) {
+	tea := model.Item{ItemName: "tea", Cost: 150, Price: 200, Sort: 1}
+	cake := model.Item{ItemName: "cake", Cost: 300, Price: 450, Sort: 2}
+	tests := []struct {
+		name   string
+		file   string
+		rows   []model.ImportRow[model.Item]
+		errors []model.ImportRowError
+		err    string
+	}{
+		{
+			name: "valid",
+			file: "item_name,cost,price,sort\ntea,1.50,2,1\ncake,3,4.50,2\n",
+			rows: []model.ImportRow[model.Item]{{Line: 2, Value: tea}, {Line: 3, Value: cake}},
+		},
+		{
+			name: "columns in any order",
+			file: "Sort, PRICE ,cost,item_name,note\n1,2.00,1.5,tea,ignored\n",
+			rows: []model.ImportRow[model.Item]{{Line: 2, Value: tea}},
+		},
+		{
+			name: "values are trimmed",
+			file: "item_name,cost,price,sort\n tea ,1.50 , 2,1\n",
+			rows: []model.ImportRow[model.Item]{{Line: 2, Value: tea}},
+		},
+		{
+			name: "amounts are rounded",
+			file: "item_name,cost,price,sort\ntea,1.495,2.004,1\n",
+			rows: []model.ImportRow[model.Item]{{Line: 2, Value: tea}},
+		},
+		{
+			name: "header only",
+			file: "item_name,cost,price,sort\n",
+		},
+		{
+			name:   "negative amount",
+			file:   "item_name,cost,price,sort\ntea,1.50,-2,1\n",
+			errors: []model.ImportRowError{{Line: 2, Error: "price is invalid or under zero"}},
+		},
+		{
+			name:   "invalid amount",
+			file:   "item_name,cost,price,sort\ntea,abc,2,1\n",
+			errors: []model.ImportRowError{{Line: 2, Error: "cost is invalid amount, cost is invalid or under zero"}},
+		},
+		{
+			name:   "invalid integer",
+			file:   "item_name,cost,price,sort\ntea,1.50,2,1.5\n",
+			errors: []model.ImportRowError{{Line: 2, Error: "sort is invalid integer, sort is invalid or under zero"}},
+		},
+		{
+			name: "short row",
+			file: "item_name,cost,price,sort\ntea,1.50\n",
+			errors: []model.ImportRowError{{
+				Line:  2,
+				Error: "price is invalid amount, sort is invalid integer, price is invalid or under zero, sort is invalid or under zero",
+			}},
+		},
+		{
+			name:   "repeated name",
+			file:   "item_name,cost,price,sort\ntea,1.50,2,1\ncake,3,4.50,2\ntea,1,1,1\n",
+			rows:   []model.ImportRow[model.Item]{{Line: 2, Value: tea}, {Line: 3, Value: cake}},
+			errors: []model.ImportRowError{{Line: 4, Error: "name repeats line 2"}},
+		},
+		{
+			name: "lines of quoted values",
+			file: "item_name,cost,price,sort\n\"te\na\",1,1,1\ntea,1.50,2,1\n",
+			rows: []model.ImportRow[model.Item]{
+				{Line: 2, Value: model.Item{ItemName: "te\na", Cost: 100, Price: 100, Sort: 1}},
+				{Line: 4, Value: tea},
+			},
+		},
+		{name: "empty file", file: "", err: "file is empty"},
+		{name: "missing column", file: "item_name,cost,price\ntea,1.50,2\n", err: "column sort is missing"},
+		{name: "invalid CSV", file: "item_name,cost,price,sort\n\"tea,1.50,2,1\n", err: "file is not valid CSV"},
+	}
+	for _, tt := range tests {
+		rows, rowErrors, err := parseItems(tt.file)
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("%s: got error %v, want %s", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.rows) {
+			t.Errorf("%s: rows are %+v, want %+v", tt.name, rows, tt.rows)
+		}
+		if !reflect.DeepEqual(rowErrors, tt.errors) {
+			t.Errorf("%s: row errors are %+v, want %+v", tt.name, rowErrors, tt.errors)
+		}
+	}
+}
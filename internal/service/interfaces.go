@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
@@ -12,23 +14,66 @@ type Service struct {
 	Item
 	Customer
 	Transaction
+	Ledger
+	Stock
+	Order
+	Return
+	Report
+	Import
+	APIKey
+	User
+	Audit
+	Idempotency
+	Tenant
 }
 
 type Repo struct {
 	ItemRepository
 	CustomerRepository
 	TransactionRepository
+	LedgerRepository
+	StockRepository
+	OrderRepository
+	ReturnRepository
+	ReportRepository
+	ImportRepository
+	APIKeyRepository
+	UserRepository
+	AuditRepository
+	IdempotencyRepository
+	TenantRepository
+	TxRepository
 }
 
-func New(repo *Repo) *Service {
+func New(repo *Repo, tokens TokenConfig, idempotencyTTL time.Duration) *Service {
+	audit := NewAuditService(repo.AuditRepository, repo.TxRepository)
 	return &Service{
-		Item:     NewItemService(repo.ItemRepository),
-		Customer: NewCustomerService(repo.CustomerRepository),
+		Item:     NewItemService(repo.ItemRepository, audit),
+		Customer: NewCustomerService(repo.CustomerRepository, audit),
 		Transaction: NewTransactionService(
 			repo.TransactionRepository,
 			repo.CustomerRepository,
 			repo.ItemRepository,
+			audit,
 		),
+		Ledger: NewLedgerService(repo.LedgerRepository, repo.CustomerRepository),
+		Stock:  NewStockService(repo.StockRepository, repo.ItemRepository),
+		Order: NewOrderService(
+			repo.OrderRepository,
+			repo.CustomerRepository,
+			repo.ItemRepository,
+		),
+		Return: NewReturnService(repo.ReturnRepository, repo.TransactionRepository, audit),
+		Report: NewReportService(repo.ReportRepository),
+		Import: NewImportService(repo.ImportRepository, audit),
+		APIKey: NewAPIKeyService(repo.APIKeyRepository),
+		User:   NewUserService(repo.UserRepository, tokens),
+		Audit:  audit,
+		Idempotency: NewIdempotencyService(
+			repo.IdempotencyRepository,
+			idempotencyTTL,
+		),
+		Tenant: NewTenantService(repo.TenantRepository),
 	}
 }
 
@@ -37,6 +82,18 @@ func NewRepo(pg *postgres.Postgres) *Repo {
 		ItemRepository:        postgresSQL.NewItemPostgres(pg),
 		CustomerRepository:    postgresSQL.NewCustomerPostgres(pg),
 		TransactionRepository: postgresSQL.NewTransactionPostgres(pg),
+		LedgerRepository:      postgresSQL.NewLedgerPostgres(pg),
+		StockRepository:       postgresSQL.NewStockPostgres(pg),
+		OrderRepository:       postgresSQL.NewOrderPostgres(pg),
+		ReturnRepository:      postgresSQL.NewReturnPostgres(pg),
+		ReportRepository:      postgresSQL.NewReportPostgres(pg),
+		ImportRepository:      postgresSQL.NewImportPostgres(pg),
+		APIKeyRepository:      postgresSQL.NewAPIKeyPostgres(pg),
+		UserRepository:        postgresSQL.NewUserPostgres(pg),
+		AuditRepository:       postgresSQL.NewAuditPostgres(pg),
+		IdempotencyRepository: postgresSQL.NewIdempotencyPostgres(pg),
+		TenantRepository:      postgresSQL.NewTenantPostgres(pg),
+		TxRepository:          postgresSQL.NewTxPostgres(pg),
 	}
 }
 
@@ -44,27 +101,100 @@ type Item interface {
 	Create(ctx context.Context, item model.Item) (int, Status)
 	GetByID(ctx context.Context, id int) (model.Item, Status)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Item, Status)
+	GetPage(ctx context.Context, cursor string, limit int) (model.Page[model.Item], Status)
 	Update(ctx context.Context, item model.Item) (model.Item, Status)
 	Delete(ctx context.Context, id int) Status
 }
 
+type Stock interface {
+	Receive(ctx context.Context, operation model.StockOperation) (int, Status)
+	Adjust(ctx context.Context, operation model.StockOperation) (int, Status)
+	GetMovements(ctx context.Context, itemID, limit, offset int) ([]model.StockMovement, Status)
+}
+
 type Customer interface {
 	Create(ctx context.Context, customer model.Customer) (int, Status)
 	GetByID(ctx context.Context, id int) (model.Customer, Status)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Customer, Status)
+	GetPage(ctx context.Context, cursor string, limit int) (model.Page[model.Customer], Status)
 	Update(ctx context.Context, customer model.Customer) (model.Customer, Status)
 	Delete(ctx context.Context, id int) Status
+	Deposit(ctx context.Context, operation model.BalanceOperation) (model.Money, Status)
+	Withdraw(ctx context.Context, operation model.BalanceOperation) (model.Money, Status)
+}
+
+type Ledger interface {
+	GetByCustomerID(ctx context.Context, customerID, limit, offset int) ([]model.BalanceEntry, Status)
+	Reconcile(ctx context.Context, customerID int) (model.LedgerReconciliation, Status)
 }
 
 type Transaction interface {
 	Create(ctx context.Context, transaction model.Transaction) (int, Status)
 	GetByID(ctx context.Context, id int) (model.Transaction, Status)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Transaction, Status)
+	GetPage(ctx context.Context, cursor string, limit int) (model.Page[model.Transaction], Status)
 	Update(ctx context.Context, transaction model.Transaction) (model.Transaction, Status)
-	Delete(ctx context.Context, id int) Status
+	Delete(ctx context.Context, id int, reason string) Status
+	Transition(ctx context.Context, id int, status, reason string) (model.Transaction, Status)
 	GetAllTransactionViews(ctx context.Context, limit, offset int) ([]model.TransactionView, Status)
+	GetTransactionViewPage(ctx context.Context, cursor string, limit int) (model.Page[model.TransactionView], Status)
 	GetByTransactionID(ctx context.Context, id int) (model.TransactionView, Status)
-	GetAllTransactionViewsByFilters(ctx context.Context, filter *model.TransactionFilter) ([]model.TransactionView, Status)
+	GetAllTransactionViewsByFilters(ctx context.Context, filter *model.TransactionFilter) (model.TransactionViewPage, Status)
+	ExportTransactionViews(filter *model.TransactionFilter) (TransactionViewStream, Status)
+}
+
+type Order interface {
+	Create(ctx context.Context, order model.Order) (int, Status)
+	GetByID(ctx context.Context, id int) (model.Order, Status)
+	GetAll(ctx context.Context, limit, offset int) ([]model.Order, Status)
+}
+
+type Return interface {
+	Create(ctx context.Context, ret model.TransactionReturn) (model.TransactionReturn, Status)
+	GetByTransactionID(ctx context.Context, transactionID int) ([]model.TransactionReturn, Status)
+}
+
+type Report interface {
+	Sales(ctx context.Context, filter model.ReportFilter) ([]model.SalesReportRow, Status)
+	Items(ctx context.Context, filter model.ReportFilter) ([]model.ItemReportRow, Status)
+	Customers(ctx context.Context, filter model.ReportFilter) ([]model.CustomerReportRow, Status)
+}
+
+type Import interface {
+	ImportItems(ctx context.Context, r io.Reader, options model.ImportOptions) (model.ImportResult, Status)
+	ImportCustomers(ctx context.Context, r io.Reader, options model.ImportOptions) (model.ImportResult, Status)
+}
+
+type APIKey interface {
+	Create(ctx context.Context, key model.APIKey) (model.NewAPIKey, Status)
+	Authenticate(ctx context.Context, key string) (model.Actor, Status)
+	GetAll(ctx context.Context) ([]model.APIKey, Status)
+	Revoke(ctx context.Context, id int) (model.APIKey, Status)
+}
+
+type User interface {
+	Create(ctx context.Context, user model.User, password string) (model.User, Status)
+	Login(ctx context.Context, username, password string) (model.Token, Status)
+	Authenticate(ctx context.Context, token string) (model.Actor, Status)
+	GetAll(ctx context.Context) ([]model.User, Status)
+	Update(ctx context.Context, update model.UserUpdate) (model.User, Status)
+}
+
+type Audit interface {
+	GetByFilter(ctx context.Context, filter model.AuditFilter) (model.AuditPage, Status)
+}
+
+type Idempotency interface {
+	Begin(ctx context.Context, key, method, url string, body []byte) (model.IdempotencyKey, Status)
+	Complete(ctx context.Context, record model.IdempotencyKey) Status
+	Release(ctx context.Context, record model.IdempotencyKey) Status
+	Purge(ctx context.Context) (int64, Status)
+}
+
+type Tenant interface {
+	Create(ctx context.Context, tenant model.Tenant) (model.Tenant, Status)
+	GetByID(ctx context.Context, id int) (model.Tenant, Status)
+	GetAll(ctx context.Context) ([]model.Tenant, Status)
 }
 
 type ItemRepository interface {
@@ -73,29 +203,122 @@ type ItemRepository interface {
 	IDByItemName(ctx context.Context, ItemName string) (int, error)
 	GetByID(ctx context.Context, id int) (model.Item, error)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Item, error)
+	GetAfter(ctx context.Context, afterID, limit int) ([]model.Item, error)
 	Update(ctx context.Context, item model.Item) (model.Item, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int, actor string) error
+}
+
+type StockRepository interface {
+	Receive(ctx context.Context, operation model.StockOperation) (int, error)
+	Adjust(ctx context.Context, operation model.StockOperation) (int, error)
+	GetMovements(ctx context.Context, itemID, limit, offset int) ([]model.StockMovement, error)
 }
 
 type CustomerRepository interface {
 	Create(ctx context.Context, customer model.Customer) (int, error)
 	IDExists(ctx context.Context, id int) (bool, error)
 	IDByName(ctx context.Context, name string) (int, error)
-	GetBalance(ctx context.Context, id int) (float64, error)
+	GetBalance(ctx context.Context, id int) (model.Money, error)
 	GetByID(ctx context.Context, id int) (model.Customer, error)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Customer, error)
+	GetAfter(ctx context.Context, afterID, limit int) ([]model.Customer, error)
 	Update(ctx context.Context, customer model.Customer) (model.Customer, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int, actor string) error
+	Deposit(ctx context.Context, operation model.BalanceOperation) (model.Money, error)
+	Withdraw(ctx context.Context, operation model.BalanceOperation) (model.Money, error)
+}
+
+type LedgerRepository interface {
+	GetByCustomerID(ctx context.Context, customerID, limit, offset int) ([]model.BalanceEntry, error)
+	Reconcile(ctx context.Context, customerID int) (model.LedgerReconciliation, error)
 }
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction model.Transaction) (int, error)
 	IDExists(ctx context.Context, id int) (bool, error)
 	GetByID(ctx context.Context, id int) (model.Transaction, error)
+	GetForUpdate(ctx context.Context, id int) (model.Transaction, error)
 	GetAll(ctx context.Context, limit, offset int) ([]model.Transaction, error)
+	GetAfter(ctx context.Context, afterID, limit int) ([]model.Transaction, error)
 	Update(ctx context.Context, transaction model.Transaction) (model.Transaction, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, id int, reason, actor string) error
+	Transition(ctx context.Context, id int, status, reason, actor string) (model.Transaction, error)
 	GetAllTransactionViews(ctx context.Context, limit, offset int) ([]model.TransactionView, error)
+	GetTransactionViewsAfter(ctx context.Context, afterID, limit int) ([]model.TransactionView, error)
 	GetByTransactionID(ctx context.Context, id int) (model.TransactionView, error)
-	GetAllTransactionViewsByFilters(ctx context.Context, filter *model.TransactionFilter) ([]model.TransactionView, error)
+	GetAllTransactionViewsByFilters(ctx context.Context, filter *model.TransactionFilter) (model.TransactionViewPage, error)
+	StreamTransactionViewsByFilters(
+		ctx context.Context,
+		filter *model.TransactionFilter,
+		fn func(model.TransactionView) error,
+	) error
+}
+
+type OrderRepository interface {
+	Create(ctx context.Context, order model.Order) (int, error)
+	IDExists(ctx context.Context, id int) (bool, error)
+	GetByID(ctx context.Context, id int) (model.Order, error)
+	GetAll(ctx context.Context, limit, offset int) ([]model.Order, error)
+}
+
+type ReturnRepository interface {
+	Create(ctx context.Context, ret model.TransactionReturn) (model.TransactionReturn, error)
+	GetByTransactionID(ctx context.Context, transactionID int) ([]model.TransactionReturn, error)
+}
+
+type ReportRepository interface {
+	Sales(ctx context.Context, filter model.ReportFilter) ([]model.SalesReportRow, error)
+	Items(ctx context.Context, filter model.ReportFilter) ([]model.ItemReportRow, error)
+	Customers(ctx context.Context, filter model.ReportFilter) ([]model.CustomerReportRow, error)
+}
+
+type ImportRepository interface {
+	ImportItems(
+		ctx context.Context,
+		rows []model.ImportRow[model.Item],
+		options model.ImportOptions,
+	) (model.ImportResult, []model.ImportChange[model.Item], error)
+	ImportCustomers(
+		ctx context.Context,
+		rows []model.ImportRow[model.Customer],
+		options model.ImportOptions,
+	) (model.ImportResult, []model.ImportChange[model.Customer], error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key model.APIKey, hash []byte) (model.APIKey, error)
+	UseByHash(ctx context.Context, hash []byte) (model.APIKey, error)
+	GetAll(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id int) (model.APIKey, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user model.User, passwordHash string) (model.User, error)
+	GetByID(ctx context.Context, id int) (model.User, error)
+	GetCredentials(ctx context.Context, username string) (model.User, string, error)
+	GetAll(ctx context.Context) ([]model.User, error)
+	Update(ctx context.Context, update model.UserUpdate, passwordHash *string) (model.User, error)
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry model.AuditEntry) error
+	GetByFilter(ctx context.Context, filter model.AuditFilter) (model.AuditPage, error)
+}
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, record model.IdempotencyKey) (model.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, record model.IdempotencyKey) error
+	Release(ctx context.Context, actor, key string) error
+	Purge(ctx context.Context) (int64, error)
+}
+
+type TenantRepository interface {
+	Create(ctx context.Context, tenant model.Tenant) (model.Tenant, error)
+	GetByID(ctx context.Context, id int) (model.Tenant, error)
+	GetAll(ctx context.Context) ([]model.Tenant, error)
+}
+
+type TxRepository interface {
+	// InTx runs fn in one database transaction, the repositories called with the ctx of fn take part in it
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
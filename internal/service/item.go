@@ -2,20 +2,39 @@ package service
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
 )
 
 type ItemService struct {
-	t ItemRepository
+	t     ItemRepository
+	audit *AuditService
 }
 
-func NewItemService(t ItemRepository) *ItemService {
-	return &ItemService{t: t}
+func NewItemService(t ItemRepository, audit *AuditService) *ItemService {
+	return &ItemService{t: t, audit: audit}
+}
+
+// getForChange returns the stored item for the before snapshot of a change
+func (s *ItemService) getForChange(ctx context.Context, id int, caller string) (model.Item, Status) {
+	var status Status
+	item, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item, status.withError(caller+" - s.t.GetByID:%w", err, "item does not exist", http.StatusNotFound)
+		}
+		return item, status.withError(caller+" - s.t.GetByID:%w", err, "couldn't get item", http.StatusInternalServerError)
+	}
+	return item, status.success("item retrieved", http.StatusOK)
 }
 
 func (s *ItemService) Create(ctx context.Context, item model.Item) (int, Status) {
+	ctx, span := tracing.Start(ctx, "ItemService.Create")
+	defer span.End()
 	var status Status
 	ID, err := s.t.IDByItemName(ctx, item.ItemName)
 	if err != nil {
@@ -34,7 +53,19 @@ func (s *ItemService) Create(ctx context.Context, item model.Item) (int, Status)
 			http.StatusBadRequest,
 		)
 	}
-	id, err := s.t.Create(ctx, item)
+	item.CreatedBy = ActorFromContext(ctx).Name
+	item.UpdatedBy = item.CreatedBy
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		id, err := s.t.Create(ctx, item)
+		if err != nil {
+			return err
+		}
+		item.ID = id
+		if created, err := s.t.GetByID(ctx, id); err == nil {
+			item = created
+		}
+		return s.audit.record(ctx, model.AuditEntityItem, model.AuditActionCreate, id, nil, item)
+	})
 	if err != nil {
 		return 0, status.withError(
 			"ItemService - Create - s.t.Create:%w",
@@ -43,10 +74,12 @@ func (s *ItemService) Create(ctx context.Context, item model.Item) (int, Status)
 			http.StatusInternalServerError,
 		)
 	}
-	return id, status.success("item succesfully created", http.StatusCreated)
+	return item.ID, status.success("item succesfully created", http.StatusCreated)
 }
 
 func (s *ItemService) GetByID(ctx context.Context, id int) (model.Item, Status) {
+	ctx, span := tracing.Start(ctx, "ItemService.GetByID")
+	defer span.End()
 	var status Status
 	var item model.Item
 	exist, err := s.t.IDExists(ctx, id)
@@ -73,6 +106,8 @@ func (s *ItemService) GetByID(ctx context.Context, id int) (model.Item, Status)
 }
 
 func (s *ItemService) GetAll(ctx context.Context, limit, offset int) ([]model.Item, Status) {
+	ctx, span := tracing.Start(ctx, "ItemService.GetAll")
+	defer span.End()
 	var status Status
 	var items []model.Item
 	items, err := s.t.GetAll(ctx, limit, offset)
@@ -87,18 +122,42 @@ func (s *ItemService) GetAll(ctx context.Context, limit, offset int) ([]model.It
 	return items, status.success("items retrieved", http.StatusOK)
 }
 
-func (s *ItemService) Update(ctx context.Context, item model.Item) (model.Item, Status) {
+func (s *ItemService) GetPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) (model.Page[model.Item], Status) {
+	ctx, span := tracing.Start(ctx, "ItemService.GetPage")
+	defer span.End()
 	var status Status
-	exist, err := s.t.IDExists(ctx, item.ID)
+	afterID, limit, err := pageQuery(cursor, limit)
 	if err != nil {
-		if !exist {
-			return item, status.withError(
-				"ItemService - Update -  s.t.IDExists:%w",
-				err,
-				"item does not exist",
-				http.StatusNotFound,
-			)
-		}
+		return model.Page[model.Item]{}, status.withError(
+			"ItemService - GetPage - pageQuery:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	items, err := s.t.GetAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return model.Page[model.Item]{}, status.withError(
+			"ItemService - GetPage - s.t.GetAfter:%w",
+			err,
+			"couldn't get all items",
+			http.StatusInternalServerError,
+		)
+	}
+	page := newPage(items, limit, func(item model.Item) int { return item.ID })
+	return page, status.success("items retrieved", http.StatusOK)
+}
+
+func (s *ItemService) Update(ctx context.Context, item model.Item) (model.Item, Status) {
+	ctx, span := tracing.Start(ctx, "ItemService.Update")
+	defer span.End()
+	before, status := s.getForChange(ctx, item.ID, "ItemService - Update")
+	if !status.Ok() {
+		return item, status
 	}
 	ID, err := s.t.IDByItemName(ctx, item.ItemName)
 	if err != nil {
@@ -119,9 +178,17 @@ func (s *ItemService) Update(ctx context.Context, item model.Item) (model.Item,
 		)
 	}
 
-	item, err = s.t.Update(ctx, item)
+	item.UpdatedBy = ActorFromContext(ctx).Name
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		updated, err := s.t.Update(ctx, item)
+		if err != nil {
+			return err
+		}
+		item = updated
+		return s.audit.record(ctx, model.AuditEntityItem, model.AuditActionUpdate, item.ID, before, item)
+	})
 	if err != nil {
-		return item, status.withError(
+		return model.Item{}, status.withError(
 			"ItemService - Update - s.t.Update:%w",
 			err,
 			"couldn't update item",
@@ -132,8 +199,18 @@ func (s *ItemService) Update(ctx context.Context, item model.Item) (model.Item,
 }
 
 func (s *ItemService) Delete(ctx context.Context, id int) Status {
-	var status Status
-	err := s.t.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "ItemService.Delete")
+	defer span.End()
+	before, status := s.getForChange(ctx, id, "ItemService - Delete")
+	if !status.Ok() {
+		return status
+	}
+	err := s.audit.change(ctx, func(ctx context.Context) error {
+		if err := s.t.Delete(ctx, id, ActorFromContext(ctx).Name); err != nil {
+			return err
+		}
+		return s.audit.record(ctx, model.AuditEntityItem, model.AuditActionDelete, id, before, nil)
+	})
 	if err != nil {
 		return status.withError(
 			"ItemService - Delete - s.t.Delete:%w",
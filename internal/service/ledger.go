@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type LedgerService struct {
+	t LedgerRepository
+	c CustomerRepository
+}
+
+func NewLedgerService(t LedgerRepository, c CustomerRepository) *LedgerService {
+	return &LedgerService{t: t, c: c}
+}
+
+func (s *LedgerService) GetByCustomerID(
+	ctx context.Context,
+	customerID, limit, offset int,
+) ([]model.BalanceEntry, Status) {
+	ctx, span := tracing.Start(ctx, "LedgerService.GetByCustomerID")
+	defer span.End()
+	var status Status
+	exist, err := s.c.IDExists(ctx, customerID)
+	if err != nil {
+		return nil, status.withError(
+			"LedgerService - GetByCustomerID - s.c.IDExists:%w",
+			err,
+			"error with customer id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return nil, status.withError(
+			"LedgerService - GetByCustomerID - s.c.IDExists:%w",
+			err,
+			"customer does not exist",
+			http.StatusNotFound,
+		)
+	}
+	entries, err := s.t.GetByCustomerID(ctx, customerID, limit, offset)
+	if err != nil {
+		return nil, status.withError(
+			"LedgerService - GetByCustomerID - s.t.GetByCustomerID:%w",
+			err,
+			"couldn't get ledger entries",
+			http.StatusInternalServerError,
+		)
+	}
+	return entries, status.success("ledger entries retrieved", http.StatusOK)
+}
+
+func (s *LedgerService) Reconcile(
+	ctx context.Context,
+	customerID int,
+) (model.LedgerReconciliation, Status) {
+	ctx, span := tracing.Start(ctx, "LedgerService.Reconcile")
+	defer span.End()
+	var status Status
+	var reconciliation model.LedgerReconciliation
+	exist, err := s.c.IDExists(ctx, customerID)
+	if err != nil {
+		return reconciliation, status.withError(
+			"LedgerService - Reconcile - s.c.IDExists:%w",
+			err,
+			"error with customer id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return reconciliation, status.withError(
+			"LedgerService - Reconcile - s.c.IDExists:%w",
+			err,
+			"customer does not exist",
+			http.StatusNotFound,
+		)
+	}
+	reconciliation, err = s.t.Reconcile(ctx, customerID)
+	if err != nil {
+		return reconciliation, status.withError(
+			"LedgerService - Reconcile - s.t.Reconcile:%w",
+			err,
+			"couldn't reconcile customer balance",
+			http.StatusInternalServerError,
+		)
+	}
+	reconciliation.Difference = reconciliation.Balance - reconciliation.LedgerBalance
+	reconciliation.Reconciled = reconciliation.Difference == 0
+	return reconciliation, status.success("customer balance reconciled", http.StatusOK)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type OrderService struct {
+	t OrderRepository
+	c CustomerRepository
+	i ItemRepository
+}
+
+func NewOrderService(t OrderRepository, c CustomerRepository, i ItemRepository) *OrderService {
+	return &OrderService{t: t, c: c, i: i}
+}
+
+func (s *OrderService) Create(ctx context.Context, order model.Order) (int, Status) {
+	ctx, span := tracing.Start(ctx, "OrderService.Create")
+	defer span.End()
+	var status Status
+	exist, err := s.c.IDExists(ctx, order.CustomerID)
+	if err != nil {
+		return 0, status.withError(
+			"OrderService - Create - s.c.IDExists:%w",
+			err,
+			"error with customer id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return 0, status.withError(
+			"OrderService - Create - s.c.IDExists:%w",
+			err,
+			"customer id does not exist",
+			http.StatusNotFound,
+		)
+	}
+
+	// every line is priced from the item catalog
+	order.Total = 0
+	for i := range order.Lines {
+		item, err := s.i.GetByID(ctx, order.Lines[i].ItemID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, status.withError(
+					"OrderService - Create - s.i.GetByID:%w",
+					err,
+					"item id does not exist",
+					http.StatusNotFound,
+				)
+			}
+			return 0, status.withError(
+				"OrderService - Create - s.i.GetByID:%w",
+				err,
+				"error with item price",
+				http.StatusInternalServerError,
+			)
+		}
+		order.Lines[i].Price = item.Price
+		order.Lines[i].Amount = item.Price.Mul(order.Lines[i].Qty)
+		order.Total += order.Lines[i].Amount
+	}
+	order.Status = model.OrderStatusPaid
+	order.CreatedBy = ActorFromContext(ctx).Name
+
+	id, err := s.t.Create(ctx, order)
+	if err != nil {
+		if errors.Is(err, model.ErrInsufficientBalance) {
+			metrics.BalanceCheckFailed(metrics.OperationOrderCreate)
+			return 0, status.withError(
+				"OrderService - Create - s.t.Create:%w",
+				err,
+				"customer balance is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, status.withError(
+				"OrderService - Create - s.t.Create:%w",
+				err,
+				"customer id does not exist",
+				http.StatusNotFound,
+			)
+		}
+		if errors.Is(err, model.ErrInsufficientStock) {
+			return 0, status.withError(
+				"OrderService - Create - s.t.Create:%w",
+				err,
+				"item stock is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		return 0, status.withError(
+			"OrderService - Create - s.t.Create:%w",
+			err,
+			"error with order creation",
+			http.StatusInternalServerError,
+		)
+	}
+	metrics.OrderCreated(order.Total)
+	return id, status.success("order succesfully created", http.StatusCreated)
+}
+
+func (s *OrderService) GetByID(ctx context.Context, id int) (model.Order, Status) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetByID")
+	defer span.End()
+	var status Status
+	var order model.Order
+	exist, err := s.t.IDExists(ctx, id)
+	if err != nil {
+		return order, status.withError(
+			"OrderService - GetByID - s.t.IDExists:%w",
+			err,
+			"error with order id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return order, status.withError(
+			"OrderService - GetByID - s.t.IDExists:%w",
+			err,
+			"order does not exist",
+			http.StatusNotFound,
+		)
+	}
+	order, err = s.t.GetByID(ctx, id)
+	if err != nil {
+		return order, status.withError(
+			"OrderService - GetByID - s.t.GetByID:%w",
+			err,
+			"couldn't get order",
+			http.StatusInternalServerError,
+		)
+	}
+	return order, status.success("order retrieved", http.StatusOK)
+}
+
+func (s *OrderService) GetAll(ctx context.Context, limit, offset int) ([]model.Order, Status) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetAll")
+	defer span.End()
+	var status Status
+	orders, err := s.t.GetAll(ctx, limit, offset)
+	if err != nil {
+		return orders, status.withError(
+			"OrderService - GetAll - s.t.GetAll:%w",
+			err,
+			"couldn't get all orders",
+			http.StatusInternalServerError,
+		)
+	}
+	return orders, status.success("orders retrieved", http.StatusOK)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 1000
+)
+
+// pageQuery decodes the cursor of a page request and applies the default limit
+func pageQuery(cursor string, limit int) (afterID, pageLimit int, err error) {
+	if limit < 0 || limit > maxPageLimit {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	afterID, err = model.DecodeCursor(cursor)
+	if err != nil {
+		return 0, 0, err
+	}
+	return afterID, limit, nil
+}
+
+// newPage builds a page out of up to limit+1 rows, the extra row only tells that there is a next page
+func newPage[T any](items []T, limit int, id func(T) int) model.Page[T] {
+	page := model.Page[T]{Items: items}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = model.EncodeCursor(id(page.Items[limit-1]))
+	}
+	return page
+}
@@ -0,0 +1,122 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type APIKeyPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewAPIKeyPostgres(pg *postgres.Postgres) *APIKeyPostgres {
+	return &APIKeyPostgres{pg: pg}
+}
+
+const APIKeyTable = "api_key"
+
+const apiKeyColumns = `id, name, prefix, scope, created_at, last_used_at, revoked_at, tenant_id`
+
+// keyTenant is the tenant whose keys are managed with ctx, nil for an unscoped ctx that manages the platform keys
+func keyTenant(ctx context.Context) *int {
+	id := tenant.ID(ctx)
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func scanAPIKey(row pgx.Row) (model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scope,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.TenantID,
+	)
+	return key, err
+}
+
+func (p *APIKeyPostgres) Create(ctx context.Context, key model.APIKey, hash []byte) (model.APIKey, error) {
+	key, err := scanAPIKey(p.pg.Pool.QueryRow(
+		ctx, `
+	INSERT INTO `+APIKeyTable+`
+	(name, prefix, key_hash, scope, tenant_id)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, hash, key.Scope, keyTenant(ctx),
+	))
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("APIKeyPostgres - Create - p.pg.Pool.QueryRow: %w", err)
+	}
+	return key, nil
+}
+
+// UseByHash returns the active key with the hash of any tenant and records its use, pgx.ErrNoRows means
+// the key is unknown or revoked
+func (p *APIKeyPostgres) UseByHash(ctx context.Context, hash []byte) (model.APIKey, error) {
+	key, err := scanAPIKey(p.pg.Pool.QueryRow(
+		ctx, `
+	UPDATE `+APIKeyTable+`
+	SET last_used_at = now()
+	WHERE key_hash = $1 AND revoked_at IS NULL
+	RETURNING `+apiKeyColumns,
+		hash,
+	))
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("APIKeyPostgres - UseByHash - p.pg.Pool.QueryRow: %w", err)
+	}
+	return key, nil
+}
+
+func (p *APIKeyPostgres) GetAll(ctx context.Context) ([]model.APIKey, error) {
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	SELECT `+apiKeyColumns+`
+	FROM `+APIKeyTable+`
+	WHERE tenant_id IS NOT DISTINCT FROM $1
+	ORDER BY id
+`, keyTenant(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("APIKeyPostgres - GetAll - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("APIKeyPostgres - GetAll - rows.Scan: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("APIKeyPostgres - GetAll - rows.Err: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke disables the key, pgx.ErrNoRows means it doesn't exist or is already revoked
+func (p *APIKeyPostgres) Revoke(ctx context.Context, id int) (model.APIKey, error) {
+	key, err := scanAPIKey(p.pg.Pool.QueryRow(
+		ctx, `
+	UPDATE `+APIKeyTable+`
+	SET revoked_at = now()
+	WHERE id = $1 AND tenant_id IS NOT DISTINCT FROM $2 AND revoked_at IS NULL
+	RETURNING `+apiKeyColumns,
+		id, keyTenant(ctx),
+	))
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("APIKeyPostgres - Revoke - p.pg.Pool.QueryRow: %w", err)
+	}
+	return key, nil
+}
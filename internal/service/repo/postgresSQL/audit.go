@@ -0,0 +1,122 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type AuditPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewAuditPostgres(pg *postgres.Postgres) *AuditPostgres {
+	return &AuditPostgres{pg: pg}
+}
+
+const AuditTable = "audit_log"
+
+const auditColumns = `id, tenant_id, actor, action, entity, entity_id, before, after, request_id, created_at`
+
+func scanAuditEntry(row pgx.Row) (model.AuditEntry, error) {
+	var entry model.AuditEntry
+	err := row.Scan(
+		&entry.ID,
+		&entry.TenantID,
+		&entry.Actor,
+		&entry.Action,
+		&entry.Entity,
+		&entry.EntityID,
+		&entry.Before,
+		&entry.After,
+		&entry.RequestID,
+		&entry.CreatedAt,
+	)
+	return entry, err
+}
+
+func (p *AuditPostgres) Create(ctx context.Context, entry model.AuditEntry) error {
+	_, err := conn(ctx, p.pg).Exec(
+		ctx, `
+	INSERT INTO `+AuditTable+`
+	(tenant_id, actor, action, entity, entity_id, before, after, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`, tenant.ID(ctx), entry.Actor, entry.Action, entry.Entity, entry.EntityID, entry.Before, entry.After, entry.RequestID,
+	)
+	if err != nil {
+		return fmt.Errorf("AuditPostgres - Create - conn.Exec: %w", err)
+	}
+	return nil
+}
+
+// getAuditQuery builds the WHERE clause of the filter in the tenant of ctx and its arguments
+func getAuditQuery(ctx context.Context, f model.AuditFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", "$"+strconv.Itoa(len(args))))
+	}
+	add("tenant_id = $?", tenant.ID(ctx))
+	if f.Actor != "" {
+		add("actor = $?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = $?", f.Action)
+	}
+	if f.Entity != "" {
+		add("entity = $?", f.Entity)
+	}
+	if f.EntityID != 0 {
+		add("entity_id = $?", f.EntityID)
+	}
+	if f.RequestID != "" {
+		add("request_id = $?", f.RequestID)
+	}
+	if f.CreatedFrom != nil {
+		add("created_at >= $?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("created_at <= $?", *f.CreatedTo)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetByFilter returns the newest entries first
+func (p *AuditPostgres) GetByFilter(ctx context.Context, filter model.AuditFilter) (model.AuditPage, error) {
+	page := model.AuditPage{Limit: filter.Limit, Offset: filter.Offset}
+	where, args := getAuditQuery(ctx, filter)
+
+	err := conn(ctx, p.pg).QueryRow(ctx, `SELECT COUNT(*) FROM `+AuditTable+where, args...).Scan(&page.Total)
+	if err != nil {
+		return model.AuditPage{}, fmt.Errorf("AuditPostgres - GetByFilter - conn.QueryRow: %w", err)
+	}
+	rows, err := conn(ctx, p.pg).Query(
+		ctx,
+		`SELECT `+auditColumns+` FROM `+AuditTable+where+` ORDER BY id DESC`+getLimitAndOffset(filter.Limit, filter.Offset),
+		args...,
+	)
+	if err != nil {
+		return model.AuditPage{}, fmt.Errorf("AuditPostgres - GetByFilter - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	page.Items = []model.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return model.AuditPage{}, fmt.Errorf("AuditPostgres - GetByFilter - rows.Scan: %w", err)
+		}
+		page.Items = append(page.Items, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return model.AuditPage{}, fmt.Errorf("AuditPostgres - GetByFilter - rows.Err: %w", err)
+	}
+	return page, nil
+}
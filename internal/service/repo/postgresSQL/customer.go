@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
 )
 
 type CustomerPostgres struct {
@@ -19,29 +20,67 @@ func NewCustomerPostgres(pg *postgres.Postgres) *CustomerPostgres {
 
 const CustomerTable = "customer"
 
+const customerColumns = `id, tenant_id, customer_name, balance, created_at, updated_at, deleted_at, created_by, updated_by`
+
+func scanCustomer(row pgx.Row) (model.Customer, error) {
+	var customer model.Customer
+	err := row.Scan(
+		&customer.ID,
+		&customer.TenantID,
+		&customer.Name,
+		&customer.Balance,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+		&customer.DeletedAt,
+		&customer.CreatedBy,
+		&customer.UpdatedBy,
+	)
+	return customer, err
+}
+
 func (p *CustomerPostgres) Create(ctx context.Context, customer model.Customer) (int, error) {
-	// insert and return id
+	// insert and return id, the initial balance is recorded as the first ledger entry
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Create - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var id int
-	err := p.pg.Pool.QueryRow(
+	err = tx.QueryRow(
 		ctx, fmt.Sprintf(
-			"INSERT INTO %s (customer_name, balance) VALUES ($1, $2) RETURNING id",
+			"INSERT INTO %s (tenant_id, customer_name, balance, created_by, updated_by) VALUES ($1, $2, $3, $4, $4) RETURNING id",
 			CustomerTable,
-		), customer.Name, customer.Balance,
+		), tenant.ID(ctx), customer.Name, customer.Balance, customer.CreatedBy,
 	).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("postgres - CustomerPostgres - Create: %w", err)
 	}
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID: id,
+		Kind:       model.BalanceEntryCredit,
+		Amount:     customer.Balance,
+		Reason:     "opening balance",
+		Operator:   customer.CreatedBy,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Create: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Create - tx.Commit: %w", err)
+	}
 	return id, nil
 }
 
 func (p *CustomerPostgres) IDExists(ctx context.Context, id int) (bool, error) {
 	// check if exists
 	var exists bool
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, fmt.Sprintf(
-			"SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)",
+			"SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL)",
 			CustomerTable,
-		), id,
+		), id, tenant.ID(ctx),
 	).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("postgres - CustomerPostgres - IDExists: %w", err)
@@ -52,11 +91,11 @@ func (p *CustomerPostgres) IDExists(ctx context.Context, id int) (bool, error) {
 func (p *CustomerPostgres) IDByName(ctx context.Context, name string) (int, error) {
 	// so check if customername exists and return it's id if not return 0
 	var id int
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, fmt.Sprintf(
-			"SELECT id FROM %s WHERE customer_name = $1",
+			"SELECT id FROM %s WHERE customer_name = $1 AND tenant_id = $2",
 			CustomerTable,
-		), name,
+		), name, tenant.ID(ctx),
 	).Scan(&id)
 	if err != nil {
 		// if err is now row return 0 else return error
@@ -68,13 +107,13 @@ func (p *CustomerPostgres) IDByName(ctx context.Context, name string) (int, erro
 	return id, nil
 }
 
-func (p *CustomerPostgres) GetBalance(ctx context.Context, id int) (float64, error) {
-	var balance float64
-	err := p.pg.Pool.QueryRow(
+func (p *CustomerPostgres) GetBalance(ctx context.Context, id int) (model.Money, error) {
+	var balance model.Money
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, fmt.Sprintf(
-			"SELECT balance FROM %s WHERE id = $1 AND deleted_at IS NULL",
+			"SELECT balance FROM %s WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL",
 			CustomerTable,
-		), id,
+		), id, tenant.ID(ctx),
 	).Scan(&balance)
 	if err != nil {
 		return 0, fmt.Errorf("postgres - CustomerPostgres - GetBalance: %w", err)
@@ -85,18 +124,21 @@ func (p *CustomerPostgres) GetBalance(ctx context.Context, id int) (float64, err
 func (p *CustomerPostgres) GetByID(ctx context.Context, id int) (model.Customer, error) {
 	// get by id
 	var customer model.Customer
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, fmt.Sprintf(
-			"SELECT id, customer_name, balance, created_at, updated_at, deleted_at FROM %s WHERE id = $1 AND  deleted_at IS NULL",
+			"SELECT id, tenant_id, customer_name, balance, created_at, updated_at, deleted_at, created_by, updated_by FROM %s WHERE id = $1 AND tenant_id = $2 AND  deleted_at IS NULL",
 			CustomerTable,
-		), id,
+		), id, tenant.ID(ctx),
 	).Scan(
 		&customer.ID,
+		&customer.TenantID,
 		&customer.Name,
 		&customer.Balance,
 		&customer.CreatedAt,
 		&customer.UpdatedAt,
 		&customer.DeletedAt,
+		&customer.CreatedBy,
+		&customer.UpdatedBy,
 	)
 	if err != nil {
 		return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - GetByID: %w", err)
@@ -108,14 +150,14 @@ func (p *CustomerPostgres) GetAll(
 	ctx context.Context,
 	limit, offset int,
 ) ([]model.Customer, error) {
-	rows, err := p.pg.Pool.Query(
+	rows, err := conn(ctx, p.pg).Query(
 		ctx, fmt.Sprintf(
-			"SELECT id, customer_name, balance, created_at, updated_at, deleted_at FROM %s WHERE deleted_at IS NULL"+getLimitAndOffset(
+			"SELECT id, tenant_id, customer_name, balance, created_at, updated_at, deleted_at, created_by, updated_by FROM %s WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id"+getLimitAndOffset(
 				limit,
 				offset,
 			),
 			CustomerTable,
-		),
+		), tenant.ID(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("postgres - CustomerPostgres - GetAll: %w", err)
@@ -127,11 +169,14 @@ func (p *CustomerPostgres) GetAll(
 		var customer model.Customer
 		err := rows.Scan(
 			&customer.ID,
+			&customer.TenantID,
 			&customer.Name,
 			&customer.Balance,
 			&customer.CreatedAt,
 			&customer.UpdatedAt,
 			&customer.DeletedAt,
+			&customer.CreatedBy,
+			&customer.UpdatedBy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("postgres - CustomerPostgres - GetAll: %w", err)
@@ -146,34 +191,182 @@ func (p *CustomerPostgres) GetAll(
 	return customers, nil
 }
 
+// GetAfter returns up to limit customers with an id greater than afterID, ordered by id
+func (p *CustomerPostgres) GetAfter(
+	ctx context.Context,
+	afterID, limit int,
+) ([]model.Customer, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT id, tenant_id, customer_name, balance, created_at, updated_at, deleted_at, created_by, updated_by
+	FROM `+CustomerTable+`
+	WHERE tenant_id = $3 AND deleted_at IS NULL AND id > $1
+	ORDER BY id
+	LIMIT $2
+`, afterID, limit, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("postgres - CustomerPostgres - GetAfter: %w", err)
+	}
+	defer rows.Close()
+
+	var customers []model.Customer
+	for rows.Next() {
+		var customer model.Customer
+		err := rows.Scan(
+			&customer.ID,
+			&customer.TenantID,
+			&customer.Name,
+			&customer.Balance,
+			&customer.CreatedAt,
+			&customer.UpdatedAt,
+			&customer.DeletedAt,
+			&customer.CreatedBy,
+			&customer.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("postgres - CustomerPostgres - GetAfter: %w", err)
+		}
+		customers = append(customers, customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres - CustomerPostgres - GetAfter: %w", err)
+	}
+
+	return customers, nil
+}
+
 func (p *CustomerPostgres) Update(
 	ctx context.Context,
 	customer model.Customer,
 ) (model.Customer, error) {
-	// update
-	_, err := p.pg.Pool.Exec(
+	// update, an overwritten balance is recorded in the ledger as a manual adjustment
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - Update - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var previous model.Money
+	err = tx.QueryRow(
 		ctx, fmt.Sprintf(
-			"UPDATE %s SET customer_name = $1, balance = $2, updated_at = now() WHERE id = $3",
+			"SELECT balance FROM %s WHERE id = $1 AND tenant_id = $2 FOR UPDATE",
 			CustomerTable,
-		), customer.Name, customer.Balance, customer.ID,
-	)
+		), customer.ID, tenant.ID(ctx),
+	).Scan(&previous)
 	if err != nil {
 		return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - Update: %w", err)
 	}
+	err = tx.QueryRow(
+		ctx, fmt.Sprintf(
+			"UPDATE %s SET customer_name = $1, balance = $2, updated_by = $3, updated_at = now() WHERE id = $4 AND tenant_id = $5"+
+				" RETURNING tenant_id, created_at, updated_at, created_by",
+			CustomerTable,
+		), customer.Name, customer.Balance, customer.UpdatedBy, customer.ID, tenant.ID(ctx),
+	).Scan(&customer.TenantID, &customer.CreatedAt, &customer.UpdatedAt, &customer.CreatedBy)
+	if err != nil {
+		return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - Update: %w", err)
+	}
+	if customer.Balance != previous {
+		err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+			CustomerID: customer.ID,
+			Kind:       model.BalanceEntryAdjustment,
+			Amount:     customer.Balance - previous,
+			Reason:     "balance overwritten",
+			Operator:   customer.UpdatedBy,
+		})
+		if err != nil {
+			return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - Update: %w", err)
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.Customer{}, fmt.Errorf("postgres - CustomerPostgres - Update - tx.Commit: %w", err)
+	}
 	return customer, nil
 }
 
-func (p *CustomerPostgres) Delete(ctx context.Context, id int) error {
+func (p *CustomerPostgres) Delete(ctx context.Context, id int, actor string) error {
 	// delete by seting deleted_at time.Now
 
-	_, err := p.pg.Pool.Exec(
+	_, err := conn(ctx, p.pg).Exec(
 		ctx, fmt.Sprintf(
-			"UPDATE %s SET deleted_at = now() WHERE id = $1",
+			"UPDATE %s SET deleted_at = now(), updated_by = $2 WHERE id = $1 AND tenant_id = $3",
 			CustomerTable,
-		), id,
+		), id, actor, tenant.ID(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("postgres - CustomerPostgres - Delete: %w", err)
 	}
 	return nil
 }
+
+func (p *CustomerPostgres) Deposit(
+	ctx context.Context,
+	operation model.BalanceOperation,
+) (model.Money, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Deposit - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var balance model.Money
+	err = tx.QueryRow(
+		ctx, fmt.Sprintf(
+			"UPDATE %s SET balance = balance + $1, updated_at = now() WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL RETURNING balance",
+			CustomerTable,
+		), operation.Amount, operation.CustomerID, tenant.ID(ctx),
+	).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Deposit: %w", err)
+	}
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID: operation.CustomerID,
+		Kind:       model.BalanceEntryCredit,
+		Amount:     operation.Amount,
+		Reason:     operation.Reason,
+		Operator:   operation.Operator,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Deposit: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Deposit - tx.Commit: %w", err)
+	}
+	return balance, nil
+}
+
+func (p *CustomerPostgres) Withdraw(
+	ctx context.Context,
+	operation model.BalanceOperation,
+) (model.Money, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Withdraw - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the balance check is part of the update so concurrent withdrawals can't overdraw
+	balance, err := debitCustomer(ctx, tx, operation.CustomerID, operation.Amount)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Withdraw - debitCustomer: %w", err)
+	}
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID: operation.CustomerID,
+		Kind:       model.BalanceEntryDebit,
+		Amount:     -operation.Amount,
+		Reason:     operation.Reason,
+		Operator:   operation.Operator,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Withdraw: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("postgres - CustomerPostgres - Withdraw - tx.Commit: %w", err)
+	}
+	return balance, nil
+}
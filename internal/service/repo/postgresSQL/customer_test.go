@@ -0,0 +1,90 @@
+package postgresSQL
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+func TestWithdrawConcurrent(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	const (
+		opening  = model.Money(1000)
+		amount   = model.Money(100)
+		deposits = 20
+		debits   = 50
+	)
+	customers := NewCustomerPostgres(pg)
+	id := testCustomer(t, ctx, pg, opening)
+
+	// deposits run alongside the debits, a lost update would show up in the final balance
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		withdrawn int
+	)
+	for i := 0; i < debits+deposits; i++ {
+		wg.Add(1)
+		go func(debit bool) {
+			defer wg.Done()
+			operation := model.BalanceOperation{CustomerID: id, Amount: amount, Reason: "test", Operator: "test"}
+			if !debit {
+				if _, err := customers.Deposit(ctx, operation); err != nil {
+					t.Errorf("Deposit: %v", err)
+				}
+				return
+			}
+			balance, err := customers.Withdraw(ctx, operation)
+			if errors.Is(err, model.ErrInsufficientBalance) {
+				return
+			}
+			if err != nil {
+				t.Errorf("Withdraw: %v", err)
+				return
+			}
+			if balance < 0 {
+				t.Errorf("Withdraw left balance %v", balance)
+			}
+			mu.Lock()
+			withdrawn++
+			mu.Unlock()
+		}(i < debits)
+	}
+	wg.Wait()
+
+	if limit := int((opening + amount*deposits) / amount); withdrawn > limit {
+		t.Errorf("%d withdrawals succeeded, the balance covers %d", withdrawn, limit)
+	}
+	customer, err := customers.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	want := opening + amount*deposits - amount.Mul(withdrawn)
+	if customer.Balance != want {
+		t.Errorf("balance is %v, want %v", customer.Balance, want)
+	}
+	if customer.Balance < 0 {
+		t.Errorf("balance went negative: %v", customer.Balance)
+	}
+}
+
+func TestWithdrawNotFound(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	other := testTenant(t, pg)
+	customers := NewCustomerPostgres(pg)
+	id := testCustomer(t, other, pg, 1000)
+
+	_, err := customers.Withdraw(ctx, model.BalanceOperation{CustomerID: id, Amount: 100, Reason: "test"})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Withdraw from another tenant: got %v, want pgx.ErrNoRows", err)
+	}
+	_, err = customers.Withdraw(other, model.BalanceOperation{CustomerID: id, Amount: 2000, Reason: "test"})
+	if !errors.Is(err, model.ErrInsufficientBalance) {
+		t.Errorf("Withdraw over the balance: got %v, want model.ErrInsufficientBalance", err)
+	}
+}
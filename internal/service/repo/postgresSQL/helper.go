@@ -2,6 +2,7 @@ package postgresSQL
 
 import (
 	"fmt"
+	"strings"
 )
 
 func getLimitAndOffset(limit, offset int) string {
@@ -15,3 +16,10 @@ func getLimitAndOffset(limit, offset int) string {
 	}
 	return limitQ + offsetQ
 }
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards of s so it is matched literally
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
@@ -0,0 +1,103 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type IdempotencyPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewIdempotencyPostgres(pg *postgres.Postgres) *IdempotencyPostgres {
+	return &IdempotencyPostgres{pg: pg}
+}
+
+const IdempotencyTable = "idempotency_key"
+
+const idempotencyColumns = `actor, key, method, path, request_hash, status_code, content_type, response_body,
+	created_at, expires_at`
+
+// Claim stores the key for a new request and reports true, an expired key is taken over.
+// A key that is already stored is returned with false, pgx.ErrNoRows means that a concurrent
+// request claimed it and hasn't committed yet. Keys are scoped to the tenant of ctx.
+func (p *IdempotencyPostgres) Claim(ctx context.Context, record model.IdempotencyKey) (model.IdempotencyKey, bool, error) {
+	var claimed bool
+	err := p.pg.Pool.QueryRow(
+		ctx, `
+	WITH claimed AS (
+		INSERT INTO `+IdempotencyTable+` AS k
+		(tenant_id, actor, key, method, path, request_hash, expires_at)
+		VALUES ($7, $1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, actor, key) DO UPDATE
+		SET method = EXCLUDED.method, path = EXCLUDED.path, request_hash = EXCLUDED.request_hash,
+			status_code = NULL, content_type = '', response_body = NULL, created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE k.expires_at < now()
+		RETURNING `+idempotencyColumns+`
+	)
+	SELECT `+idempotencyColumns+`, true FROM claimed
+	UNION ALL
+	SELECT `+idempotencyColumns+`, false FROM `+IdempotencyTable+`
+	WHERE tenant_id = $7 AND actor = $1 AND key = $2 AND NOT EXISTS (SELECT 1 FROM claimed)
+`, record.Actor, record.Key, record.Method, record.Path, record.RequestHash, record.ExpiresAt, tenant.ID(ctx),
+	).Scan(
+		&record.Actor,
+		&record.Key,
+		&record.Method,
+		&record.Path,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+		&claimed,
+	)
+	if err != nil {
+		return model.IdempotencyKey{}, false, fmt.Errorf("IdempotencyPostgres - Claim - p.pg.Pool.QueryRow: %w", err)
+	}
+	return record, claimed, nil
+}
+
+// Complete stores the response of the request that claimed the key
+func (p *IdempotencyPostgres) Complete(ctx context.Context, record model.IdempotencyKey) error {
+	_, err := p.pg.Pool.Exec(
+		ctx, `
+	UPDATE `+IdempotencyTable+`
+	SET status_code = $3, content_type = $4, response_body = $5
+	WHERE tenant_id = $6 AND actor = $1 AND key = $2
+`, record.Actor, record.Key, record.StatusCode, record.ContentType, record.ResponseBody, tenant.ID(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("IdempotencyPostgres - Complete - p.pg.Pool.Exec: %w", err)
+	}
+	return nil
+}
+
+// Release forgets a key whose request failed, so it can be retried
+func (p *IdempotencyPostgres) Release(ctx context.Context, actor, key string) error {
+	_, err := p.pg.Pool.Exec(
+		ctx, `
+	DELETE FROM `+IdempotencyTable+`
+	WHERE tenant_id = $3 AND actor = $1 AND key = $2 AND status_code IS NULL
+`, actor, key, tenant.ID(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("IdempotencyPostgres - Release - p.pg.Pool.Exec: %w", err)
+	}
+	return nil
+}
+
+// Purge deletes the expired keys of every tenant and returns how many there were
+func (p *IdempotencyPostgres) Purge(ctx context.Context) (int64, error) {
+	tag, err := p.pg.Pool.Exec(ctx, `DELETE FROM `+IdempotencyTable+` WHERE expires_at < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("IdempotencyPostgres - Purge - p.pg.Pool.Exec: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
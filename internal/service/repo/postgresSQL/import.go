@@ -0,0 +1,214 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type ImportPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewImportPostgres(pg *postgres.Postgres) *ImportPostgres {
+	return &ImportPostgres{pg: pg}
+}
+
+// importInTx runs fn in a single transaction that is committed only if fn reported no row errors
+// and the import is not a dry run. The changes fn collects are returned only when they are committed.
+func importInTx[T any](
+	ctx context.Context,
+	pg *postgres.Postgres,
+	options model.ImportOptions,
+	fn func(tx pgx.Tx, result *model.ImportResult, changes *[]model.ImportChange[T]) error,
+) (model.ImportResult, []model.ImportChange[T], error) {
+	result := model.ImportResult{DryRun: options.DryRun}
+	tx, err := conn(ctx, pg).Begin(ctx)
+	if err != nil {
+		return result, nil, fmt.Errorf("conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var changes []model.ImportChange[T]
+	err = fn(tx, &result, &changes)
+	if err != nil {
+		return result, nil, err
+	}
+	if len(result.Errors) > 0 || options.DryRun {
+		return result, nil, nil
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return result, nil, fmt.Errorf("tx.Commit: %w", err)
+	}
+	return result, changes, nil
+}
+
+// lockByName returns the row of table named name in the tenant and locks it, nil if there is none
+func lockByName[T any](
+	ctx context.Context,
+	tx pgx.Tx,
+	table, columns, column, name string,
+	scan func(pgx.Row) (T, error),
+) (*T, error) {
+	row, err := scan(tx.QueryRow(
+		ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1 AND tenant_id = $2 FOR UPDATE", columns, table, column),
+		name, tenant.ID(ctx),
+	))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (p *ImportPostgres) ImportItems(
+	ctx context.Context,
+	rows []model.ImportRow[model.Item],
+	options model.ImportOptions,
+) (model.ImportResult, []model.ImportChange[model.Item], error) {
+	result, changes, err := importInTx(ctx, p.pg, options, func(
+		tx pgx.Tx,
+		result *model.ImportResult,
+		changes *[]model.ImportChange[model.Item],
+	) error {
+		result.Rows = len(rows)
+		for _, row := range rows {
+			item := row.Value
+			previous, err := lockByName(ctx, tx, ItemTable, itemColumns, "item_name", item.ItemName, scanItem)
+			if err != nil {
+				return fmt.Errorf("lockByName: %w", err)
+			}
+			switch {
+			case previous != nil && !options.Upsert:
+				result.Errors = append(result.Errors, model.ImportRowError{
+					Line:  row.Line,
+					Error: "item " + item.ItemName + " already exists",
+				})
+			case previous != nil:
+				// a deleted item is brought back by the import
+				item, err = scanItem(tx.QueryRow(
+					ctx, `
+	UPDATE `+ItemTable+`
+	SET cost = $2, price = $3, sort = $4, updated_by = $5, updated_at = now(), deleted_at = NULL
+	WHERE id = $1
+	RETURNING `+itemColumns,
+					previous.ID, item.Cost, item.Price, item.Sort, item.UpdatedBy,
+				))
+				if err != nil {
+					return fmt.Errorf("tx.QueryRow: %w", err)
+				}
+				*changes = append(*changes, model.ImportChange[model.Item]{Before: previous, After: item})
+				result.Updated++
+			default:
+				item, err = scanItem(tx.QueryRow(
+					ctx, `
+	INSERT INTO `+ItemTable+` (tenant_id, item_name, cost, price, sort, created_by, updated_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $6)
+	RETURNING `+itemColumns,
+					tenant.ID(ctx), item.ItemName, item.Cost, item.Price, item.Sort, item.CreatedBy,
+				))
+				if err != nil {
+					return fmt.Errorf("tx.QueryRow: %w", err)
+				}
+				*changes = append(*changes, model.ImportChange[model.Item]{After: item})
+				result.Created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, nil, fmt.Errorf("ImportPostgres - ImportItems - importInTx: %w", err)
+	}
+	return result, changes, nil
+}
+
+func (p *ImportPostgres) ImportCustomers(
+	ctx context.Context,
+	rows []model.ImportRow[model.Customer],
+	options model.ImportOptions,
+) (model.ImportResult, []model.ImportChange[model.Customer], error) {
+	result, changes, err := importInTx(ctx, p.pg, options, func(
+		tx pgx.Tx,
+		result *model.ImportResult,
+		changes *[]model.ImportChange[model.Customer],
+	) error {
+		result.Rows = len(rows)
+		for _, row := range rows {
+			customer := row.Value
+			previous, err := lockByName(ctx, tx, CustomerTable, customerColumns, "customer_name", customer.Name, scanCustomer)
+			if err != nil {
+				return fmt.Errorf("lockByName: %w", err)
+			}
+			switch {
+			case previous != nil && !options.Upsert:
+				result.Errors = append(result.Errors, model.ImportRowError{
+					Line:  row.Line,
+					Error: "customer " + customer.Name + " already exists",
+				})
+			case previous != nil:
+				// the imported balance overwrites the stored one like an update does
+				customer, err = scanCustomer(tx.QueryRow(
+					ctx, `
+	UPDATE `+CustomerTable+`
+	SET balance = $2, updated_by = $3, updated_at = now(), deleted_at = NULL
+	WHERE id = $1
+	RETURNING `+customerColumns,
+					previous.ID, customer.Balance, customer.UpdatedBy,
+				))
+				if err != nil {
+					return fmt.Errorf("tx.QueryRow: %w", err)
+				}
+				if customer.Balance != previous.Balance {
+					err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+						CustomerID: customer.ID,
+						Kind:       model.BalanceEntryAdjustment,
+						Amount:     customer.Balance - previous.Balance,
+						Reason:     "balance imported",
+						Operator:   customer.UpdatedBy,
+					})
+					if err != nil {
+						return fmt.Errorf("insertBalanceEntry: %w", err)
+					}
+				}
+				*changes = append(*changes, model.ImportChange[model.Customer]{Before: previous, After: customer})
+				result.Updated++
+			default:
+				customer, err = scanCustomer(tx.QueryRow(
+					ctx, `
+	INSERT INTO `+CustomerTable+` (tenant_id, customer_name, balance, created_by, updated_by)
+	VALUES ($1, $2, $3, $4, $4)
+	RETURNING `+customerColumns,
+					tenant.ID(ctx), customer.Name, customer.Balance, customer.CreatedBy,
+				))
+				if err != nil {
+					return fmt.Errorf("tx.QueryRow: %w", err)
+				}
+				err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+					CustomerID: customer.ID,
+					Kind:       model.BalanceEntryCredit,
+					Amount:     customer.Balance,
+					Reason:     "opening balance",
+					Operator:   customer.CreatedBy,
+				})
+				if err != nil {
+					return fmt.Errorf("insertBalanceEntry: %w", err)
+				}
+				*changes = append(*changes, model.ImportChange[model.Customer]{After: customer})
+				result.Created++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, nil, fmt.Errorf("ImportPostgres - ImportCustomers - importInTx: %w", err)
+	}
+	return result, changes, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
 )
 
 type ItemPostgres struct {
@@ -19,18 +20,39 @@ func NewItemPostgres(pg *postgres.Postgres) *ItemPostgres {
 
 const ItemTable = "item"
 
+const itemColumns = `id, tenant_id, item_name, cost, price, sort, stock, created_at, updated_at, deleted_at, created_by, updated_by`
+
+func scanItem(row pgx.Row) (model.Item, error) {
+	var item model.Item
+	err := row.Scan(
+		&item.ID,
+		&item.TenantID,
+		&item.ItemName,
+		&item.Cost,
+		&item.Price,
+		&item.Sort,
+		&item.Stock,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.DeletedAt,
+		&item.CreatedBy,
+		&item.UpdatedBy,
+	)
+	return item, err
+}
+
 func (p *ItemPostgres) Create(ctx context.Context, item model.Item) (int, error) {
 	// insert
-	query := `INSERT INTO ` + ItemTable + ` (item_name, cost, price, sort) 
-	VALUES ($1, $2, $3, $4 ) 
+	query := `INSERT INTO ` + ItemTable + ` (tenant_id, item_name, cost, price, sort, created_by, updated_by) 
+	VALUES ($1, $2, $3, $4, $5, $6, $6) 
 	RETURNING id`
 
 	var id int
-	err := p.pg.Pool.QueryRow(
-		ctx, query, item.ItemName, item.Cost, item.Price, item.Sort,
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, query, tenant.ID(ctx), item.ItemName, item.Cost, item.Price, item.Sort, item.CreatedBy,
 	).Scan(&id)
 	if err != nil {
-		return 0, fmt.Errorf("postgres - ItemPostgres.Create - p.pg.Pool.QueryRow: %w", err)
+		return 0, fmt.Errorf("postgres - ItemPostgres.Create - conn.QueryRow: %w", err)
 	}
 
 	return id, nil
@@ -38,12 +60,12 @@ func (p *ItemPostgres) Create(ctx context.Context, item model.Item) (int, error)
 
 func (p *ItemPostgres) IDExists(ctx context.Context, id int) (bool, error) {
 	// check if exists
-	query := `SELECT EXISTS(SELECT 1 FROM ` + ItemTable + ` WHERE id = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM ` + ItemTable + ` WHERE id = $1 AND tenant_id = $2)`
 
 	var exists bool
-	err := p.pg.Pool.QueryRow(ctx, query, id).Scan(&exists)
+	err := conn(ctx, p.pg).QueryRow(ctx, query, id, tenant.ID(ctx)).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("postgres - ItemPostgres.IDExist - p.pg.Pool.QueryRow: %w", err)
+		return false, fmt.Errorf("postgres - ItemPostgres.IDExist - conn.QueryRow: %w", err)
 	}
 
 	return exists, nil
@@ -52,35 +74,35 @@ func (p *ItemPostgres) IDExists(ctx context.Context, id int) (bool, error) {
 func (p *ItemPostgres) IDByItemName(ctx context.Context, ItemName string) (int, error) {
 	// so check if itemname exists and return it's id if not return 0
 	var id int
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, fmt.Sprintf(
-			"SELECT id FROM %s WHERE item_name = $1",
+			"SELECT id FROM %s WHERE item_name = $1 AND tenant_id = $2",
 			ItemTable,
-		), ItemName,
+		), ItemName, tenant.ID(ctx),
 	).Scan(&id)
 	if err != nil {
 		// if err is now row return 0 else return error
 		if err == pgx.ErrNoRows {
 			return 0, nil
 		}
-		return 0, fmt.Errorf("postgres - ItemPostgres.IDByItemName - p.pg.Pool.QueryRow: %w", err)
+		return 0, fmt.Errorf("postgres - ItemPostgres.IDByItemName - conn.QueryRow: %w", err)
 	}
 
 	return id, nil
 }
 
 func (p *ItemPostgres) GetByID(ctx context.Context, id int) (model.Item, error) {
-	query := `SELECT id, item_name, cost, price, sort, created_at, updated_at, deleted_at 
-	FROM ` + ItemTable + ` WHERE id = $1 AND  deleted_at IS NULL`
+	query := `SELECT id, tenant_id, item_name, cost, price, sort, stock, created_at, updated_at, deleted_at, created_by, updated_by 
+	FROM ` + ItemTable + ` WHERE id = $1 AND tenant_id = $2 AND  deleted_at IS NULL`
 
 	var item model.Item
-	err := p.pg.Pool.QueryRow(ctx, query, id).Scan(
-		&item.ID, &item.ItemName, &item.Cost, &item.Price, &item.Sort, &item.CreatedAt, &item.UpdatedAt,
-		&item.DeletedAt,
+	err := conn(ctx, p.pg).QueryRow(ctx, query, id, tenant.ID(ctx)).Scan(
+		&item.ID, &item.TenantID, &item.ItemName, &item.Cost, &item.Price, &item.Sort, &item.Stock, &item.CreatedAt, &item.UpdatedAt,
+		&item.DeletedAt, &item.CreatedBy, &item.UpdatedBy,
 	)
 	if err != nil {
 		return model.Item{}, fmt.Errorf(
-			"postgres - ItemPostgres.GetByID - p.pg.Pool.QueryRow: %w",
+			"postgres - ItemPostgres.GetByID - conn.QueryRow: %w",
 			err,
 		)
 	}
@@ -89,13 +111,12 @@ func (p *ItemPostgres) GetByID(ctx context.Context, id int) (model.Item, error)
 }
 
 func (p *ItemPostgres) GetAll(ctx context.Context, limit, offset int) ([]model.Item, error) {
-	query := `SELECT id, item_name, cost, price, sort, created_at, updated_at, deleted_at 
-FROM ` + ItemTable + " WHERE deleted_at IS NULL" + getLimitAndOffset(limit, offset)
-	fmt.Println(getLimitAndOffset(limit, offset))
+	query := `SELECT id, tenant_id, item_name, cost, price, sort, stock, created_at, updated_at, deleted_at, created_by, updated_by 
+FROM ` + ItemTable + " WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id" + getLimitAndOffset(limit, offset)
 
-	rows, err := p.pg.Pool.Query(ctx, query)
+	rows, err := conn(ctx, p.pg).Query(ctx, query, tenant.ID(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("postgres - ItemPostgres.GetAll - p.pg.Pool.Query: %w", err)
+		return nil, fmt.Errorf("postgres - ItemPostgres.GetAll - conn.Query: %w", err)
 	}
 	defer rows.Close()
 
@@ -104,13 +125,17 @@ FROM ` + ItemTable + " WHERE deleted_at IS NULL" + getLimitAndOffset(limit, offs
 		var item model.Item
 		err := rows.Scan(
 			&item.ID,
+			&item.TenantID,
 			&item.ItemName,
 			&item.Cost,
 			&item.Price,
 			&item.Sort,
+			&item.Stock,
 			&item.CreatedAt,
 			&item.UpdatedAt,
 			&item.DeletedAt,
+			&item.CreatedBy,
+			&item.UpdatedBy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("postgres - ItemPostgres.GetAll - rows.Scan: %w", err)
@@ -126,26 +151,74 @@ FROM ` + ItemTable + " WHERE deleted_at IS NULL" + getLimitAndOffset(limit, offs
 	return items, nil
 }
 
+// GetAfter returns up to limit items with an id greater than afterID, ordered by id
+func (p *ItemPostgres) GetAfter(ctx context.Context, afterID, limit int) ([]model.Item, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT id, tenant_id, item_name, cost, price, sort, stock, created_at, updated_at, deleted_at, created_by, updated_by
+	FROM `+ItemTable+`
+	WHERE tenant_id = $3 AND deleted_at IS NULL AND id > $1
+	ORDER BY id
+	LIMIT $2
+`, afterID, limit, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("postgres - ItemPostgres.GetAfter - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var items []model.Item
+	for rows.Next() {
+		var item model.Item
+		err := rows.Scan(
+			&item.ID,
+			&item.TenantID,
+			&item.ItemName,
+			&item.Cost,
+			&item.Price,
+			&item.Sort,
+			&item.Stock,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.DeletedAt,
+			&item.CreatedBy,
+			&item.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("postgres - ItemPostgres.GetAfter - rows.Scan: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres - ItemPostgres.GetAfter - rows.Err: %w", err)
+	}
+
+	return items, nil
+}
+
 func (p *ItemPostgres) Update(ctx context.Context, item model.Item) (model.Item, error) {
-	query := `UPDATE ` + ItemTable + ` SET  item_name=$1, cost=$2, price=$3, sort=$4, updated_at= now()  WHERE id=$5`
+	// stock is only changed through stock movements
+	query := `UPDATE ` + ItemTable + ` SET  item_name=$1, cost=$2, price=$3, sort=$4, updated_by=$6, updated_at= now()  WHERE id=$5 AND tenant_id=$7
+	RETURNING tenant_id, stock, created_at, updated_at, created_by`
 
-	_, err := p.pg.Pool.Exec(
-		ctx, query, item.ItemName, item.Cost, item.Price, item.Sort, item.ID,
-	)
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, query, item.ItemName, item.Cost, item.Price, item.Sort, item.ID, item.UpdatedBy, tenant.ID(ctx),
+	).Scan(&item.TenantID, &item.Stock, &item.CreatedAt, &item.UpdatedAt, &item.CreatedBy)
 	if err != nil {
-		return model.Item{}, fmt.Errorf("postgres - ItemPostgres.Update - p.pg.Pool.Exec: %w", err)
+		return model.Item{}, fmt.Errorf("postgres - ItemPostgres.Update - conn.QueryRow: %w", err)
 	}
 
 	return item, nil
 }
 
-func (p *ItemPostgres) Delete(ctx context.Context, id int) error {
+func (p *ItemPostgres) Delete(ctx context.Context, id int, actor string) error {
 	// delete by setting deleted_at to time.Now
-	query := `UPDATE ` + ItemTable + ` SET deleted_at= now() WHERE id=$1`
+	query := `UPDATE ` + ItemTable + ` SET deleted_at= now(), updated_by=$2 WHERE id=$1 AND tenant_id=$3`
 
-	_, err := p.pg.Pool.Exec(ctx, query, id)
+	_, err := conn(ctx, p.pg).Exec(ctx, query, id, actor, tenant.ID(ctx))
 	if err != nil {
-		return fmt.Errorf("postgres - ItemPostgres.Delete - p.pg.Pool.Exec: %w", err)
+		return fmt.Errorf("postgres - ItemPostgres.Delete - conn.Exec: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,111 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type LedgerPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewLedgerPostgres(pg *postgres.Postgres) *LedgerPostgres {
+	return &LedgerPostgres{pg: pg}
+}
+
+const BalanceEntryTable = "balance_entry"
+
+// insertBalanceEntry appends a ledger row inside tx, it must be called right after the customer
+// balance was changed so that balance_after is taken from the updated row
+func insertBalanceEntry(ctx context.Context, tx pgx.Tx, entry model.BalanceEntry) error {
+	_, err := tx.Exec(
+		ctx, `
+	INSERT INTO `+BalanceEntryTable+`
+	(customer_id, transaction_id, order_id, kind, amount, balance_after, reason, operator)
+	SELECT id, $2, $3, $4, $5, balance, $6, $7
+	FROM `+CustomerTable+`
+	WHERE id = $1
+`, entry.CustomerID, entry.TransactionID, entry.OrderID, entry.Kind, entry.Amount, entry.Reason, entry.Operator,
+	)
+	if err != nil {
+		return fmt.Errorf("insertBalanceEntry - tx.Exec: %w", err)
+	}
+	return nil
+}
+
+func (p *LedgerPostgres) GetByCustomerID(
+	ctx context.Context,
+	customerID, limit, offset int,
+) ([]model.BalanceEntry, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT b.id, b.customer_id, b.transaction_id, b.order_id, b.kind, b.amount, b.balance_after, b.reason, b.operator, b.created_at
+	FROM `+BalanceEntryTable+` AS b
+	JOIN `+CustomerTable+` AS c ON c.id = b.customer_id
+	WHERE b.customer_id = $1 AND c.tenant_id = $2
+	ORDER BY b.id`+getLimitAndOffset(limit, offset), customerID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("LedgerPostgres - GetByCustomerID - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.BalanceEntry
+	for rows.Next() {
+		var entry model.BalanceEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.CustomerID,
+			&entry.TransactionID,
+			&entry.OrderID,
+			&entry.Kind,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.Reason,
+			&entry.Operator,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("LedgerPostgres - GetByCustomerID - rows.Scan: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("LedgerPostgres - GetByCustomerID - rows.Err: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (p *LedgerPostgres) Reconcile(
+	ctx context.Context,
+	customerID int,
+) (model.LedgerReconciliation, error) {
+	reconciliation := model.LedgerReconciliation{CustomerID: customerID}
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, `
+	SELECT c.balance, COALESCE(SUM(b.amount), 0), COUNT(b.id)
+	FROM `+CustomerTable+` AS c
+	LEFT JOIN `+BalanceEntryTable+` AS b ON b.customer_id = c.id
+	WHERE c.id = $1 AND c.tenant_id = $2
+	GROUP BY c.id, c.balance
+`, customerID, tenant.ID(ctx),
+	).Scan(
+		&reconciliation.Balance,
+		&reconciliation.LedgerBalance,
+		&reconciliation.Entries,
+	)
+	if err != nil {
+		return model.LedgerReconciliation{}, fmt.Errorf(
+			"LedgerPostgres - Reconcile - conn.QueryRow: %w",
+			err,
+		)
+	}
+	return reconciliation, nil
+}
@@ -0,0 +1,222 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type OrderPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewOrderPostgres(pg *postgres.Postgres) *OrderPostgres {
+	return &OrderPostgres{pg: pg}
+}
+
+const (
+	OrderTable     = "order_header"
+	OrderLineTable = "order_line"
+)
+
+func (p *OrderPostgres) Create(ctx context.Context, order model.Order) (int, error) {
+	// the customer is charged once for the whole basket, any failing line rolls back the order
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("OrderPostgres - Create - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = debitCustomer(ctx, tx, order.CustomerID, order.Total)
+	if err != nil {
+		return 0, fmt.Errorf("OrderPostgres - Create - debitCustomer: %w", err)
+	}
+	var id int
+	err = tx.QueryRow(
+		ctx, `
+	INSERT INTO `+OrderTable+`
+	(tenant_id, customer_id, status, total, created_by)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
+`, tenant.ID(ctx), order.CustomerID, order.Status, order.Total, order.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("OrderPostgres - Create - tx.QueryRow: %w", err)
+	}
+	for _, line := range order.Lines {
+		_, err = tx.Exec(
+			ctx, `
+	INSERT INTO `+OrderLineTable+`
+	(order_id, item_id, qty, price, amount)
+	VALUES ($1, $2, $3, $4, $5)
+`, id, line.ItemID, line.Qty, line.Price, line.Amount,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("OrderPostgres - Create - tx.Exec: %w", err)
+		}
+	}
+
+	// item rows are locked in id order so concurrent baskets can't deadlock each other
+	lines := make([]model.OrderLine, len(order.Lines))
+	copy(lines, order.Lines)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].ItemID < lines[j].ItemID })
+	for _, line := range lines {
+		err = takeStock(ctx, tx, model.StockMovement{
+			ItemID:   line.ItemID,
+			OrderID:  &id,
+			Qty:      line.Qty,
+			Operator: order.CreatedBy,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("OrderPostgres - Create - takeStock: %w", err)
+		}
+	}
+
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID: order.CustomerID,
+		OrderID:    &id,
+		Kind:       model.BalanceEntryDebit,
+		Amount:     -order.Total,
+		Reason:     "order",
+		Operator:   order.CreatedBy,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("OrderPostgres - Create - insertBalanceEntry: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("OrderPostgres - Create - tx.Commit: %w", err)
+	}
+	return id, nil
+}
+
+func (p *OrderPostgres) IDExists(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, `SELECT EXISTS(SELECT 1 FROM `+OrderTable+` WHERE id = $1 AND tenant_id = $2)`, id, tenant.ID(ctx),
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("OrderPostgres - IDExists - conn.QueryRow: %w", err)
+	}
+	return exists, nil
+}
+
+func (p *OrderPostgres) GetByID(ctx context.Context, id int) (model.Order, error) {
+	var order model.Order
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, `
+	SELECT id, tenant_id, customer_id, status, total, created_at, updated_at, created_by
+	FROM `+OrderTable+`
+	WHERE id = $1 AND tenant_id = $2
+`, id, tenant.ID(ctx),
+	).Scan(
+		&order.ID,
+		&order.TenantID,
+		&order.CustomerID,
+		&order.Status,
+		&order.Total,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.CreatedBy,
+	)
+	if err != nil {
+		return model.Order{}, fmt.Errorf("OrderPostgres - GetByID - conn.QueryRow: %w", err)
+	}
+	lines, err := p.getLines(ctx, []int{id})
+	if err != nil {
+		return model.Order{}, fmt.Errorf("OrderPostgres - GetByID - p.getLines: %w", err)
+	}
+	order.Lines = lines[id]
+	return order, nil
+}
+
+func (p *OrderPostgres) GetAll(ctx context.Context, limit, offset int) ([]model.Order, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT id, tenant_id, customer_id, status, total, created_at, updated_at, created_by
+	FROM `+OrderTable+`
+	WHERE tenant_id = $1
+	ORDER BY id`+getLimitAndOffset(limit, offset), tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("OrderPostgres - GetAll - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	var ids []int
+	for rows.Next() {
+		var order model.Order
+		err := rows.Scan(
+			&order.ID,
+			&order.TenantID,
+			&order.CustomerID,
+			&order.Status,
+			&order.Total,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+			&order.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("OrderPostgres - GetAll - rows.Scan: %w", err)
+		}
+		orders = append(orders, order)
+		ids = append(ids, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("OrderPostgres - GetAll - rows.Err: %w", err)
+	}
+
+	lines, err := p.getLines(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("OrderPostgres - GetAll - p.getLines: %w", err)
+	}
+	for i := range orders {
+		orders[i].Lines = lines[orders[i].ID]
+	}
+	return orders, nil
+}
+
+// getLines returns the lines of the given orders grouped by order id
+func (p *OrderPostgres) getLines(ctx context.Context, orderIDs []int) (map[int][]model.OrderLine, error) {
+	lines := make(map[int][]model.OrderLine, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return lines, nil
+	}
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT id, order_id, item_id, qty, price, amount
+	FROM `+OrderLineTable+`
+	WHERE order_id = ANY($1)
+	ORDER BY id
+`, orderIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.OrderLine
+		err := rows.Scan(
+			&line.ID,
+			&line.OrderID,
+			&line.ItemID,
+			&line.Qty,
+			&line.Price,
+			&line.Amount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		lines[line.OrderID] = append(lines[line.OrderID], line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,67 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/robertt3kuk/xiaoma-test-task/init/migrator"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+// testPostgres connects to the database at PG_URL and migrates it, the test is skipped when PG_URL is unset
+func testPostgres(t *testing.T) *postgres.Postgres {
+	t.Helper()
+	url := os.Getenv("PG_URL")
+	if url == "" {
+		t.Skip("PG_URL is not set")
+	}
+	m, err := migrator.New(url)
+	if err != nil {
+		t.Fatalf("migrator.New: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil {
+		t.Fatalf("m.Up: %v", err)
+	}
+	pg, err := postgres.New(url, postgres.MaxPoolSize(20))
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(pg.Close)
+	return pg
+}
+
+// testTenant creates a new tenant and returns a context scoped to it, so tests don't see each other's rows
+func testTenant(t *testing.T, pg *postgres.Postgres) context.Context {
+	t.Helper()
+	slug := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	created, err := NewTenantPostgres(pg).Create(context.Background(), model.Tenant{Slug: slug, Name: slug})
+	if err != nil {
+		t.Fatalf("TenantPostgres.Create: %v", err)
+	}
+	return tenant.WithID(context.Background(), created.ID)
+}
+
+func testCustomer(t *testing.T, ctx context.Context, pg *postgres.Postgres, balance model.Money) int {
+	t.Helper()
+	id, err := NewCustomerPostgres(pg).Create(ctx, model.Customer{Name: "customer", Balance: balance, CreatedBy: "test"})
+	if err != nil {
+		t.Fatalf("CustomerPostgres.Create: %v", err)
+	}
+	return id
+}
+
+// testItem creates an item without tracked stock, so sales are limited by the balance only
+func testItem(t *testing.T, ctx context.Context, pg *postgres.Postgres, price model.Money) int {
+	t.Helper()
+	id, err := NewItemPostgres(pg).Create(ctx, model.Item{ItemName: "item", Cost: price, Price: price, CreatedBy: "test"})
+	if err != nil {
+		t.Fatalf("ItemPostgres.Create: %v", err)
+	}
+	return id
+}
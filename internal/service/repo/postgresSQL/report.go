@@ -0,0 +1,162 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type ReportPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewReportPostgres(pg *postgres.Postgres) *ReportPostgres {
+	return &ReportPostgres{pg: pg}
+}
+
+// saleQuery lists every sold line of the tenant in $1 once: charged transactions net of their returns and the
+// lines of paid orders. The created_at conditions of the filter are added by getSaleQuery.
+const saleQuery = `
+	SELECT t.created_at, t.customer_id, t.item_id, t.qty - t.returned_qty AS units, t.amount - t.returned_amount AS revenue
+	FROM ` + TransactionTable + ` AS t
+	WHERE t.tenant_id = $1 AND t.deleted_at IS NULL AND t.status IN ('` + model.TransactionStatusPaid + `', '` + model.TransactionStatusFulfilled + `')%[1]s
+	UNION ALL
+	SELECT o.created_at, o.customer_id, l.item_id, l.qty, l.amount
+	FROM ` + OrderLineTable + ` AS l
+	INNER JOIN ` + OrderTable + ` AS o ON o.id = l.order_id
+	WHERE o.tenant_id = $1 AND o.status = '` + model.OrderStatusPaid + `'%[2]s`
+
+// getSaleQuery returns the sale rows of the filter period and the arguments, further arguments start at len(args)+1
+func getSaleQuery(ctx context.Context, filter model.ReportFilter) (string, []interface{}) {
+	var transactionQ, orderQ string
+	args := []interface{}{tenant.ID(ctx)}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		transactionQ += " AND t.created_at >= $" + strconv.Itoa(len(args))
+		orderQ += " AND o.created_at >= $" + strconv.Itoa(len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		transactionQ += " AND t.created_at <= $" + strconv.Itoa(len(args))
+		orderQ += " AND o.created_at <= $" + strconv.Itoa(len(args))
+	}
+	return fmt.Sprintf(saleQuery, transactionQ, orderQ), args
+}
+
+func (p *ReportPostgres) Sales(ctx context.Context, filter model.ReportFilter) ([]model.SalesReportRow, error) {
+	query, args := getSaleQuery(ctx, filter)
+	args = append(args, filter.GroupBy)
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	WITH sale AS (`+query+`
+	)
+	SELECT date_trunc($`+strconv.Itoa(len(args))+`, s.created_at) AS period,
+		SUM(s.units), SUM(s.revenue), SUM(s.units * i.cost)
+	FROM sale AS s
+	INNER JOIN `+ItemTable+` AS i ON i.id = s.item_id
+	GROUP BY 1
+	ORDER BY 1
+`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Sales - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var report []model.SalesReportRow
+	for rows.Next() {
+		var row model.SalesReportRow
+		err := rows.Scan(&row.Period, &row.Units, &row.Revenue, &row.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("ReportPostgres - Sales - rows.Scan: %w", err)
+		}
+		row.Margin = row.Revenue - row.Cost
+		report = append(report, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Sales - rows.Err: %w", err)
+	}
+
+	return report, nil
+}
+
+func (p *ReportPostgres) Items(ctx context.Context, filter model.ReportFilter) ([]model.ItemReportRow, error) {
+	query, args := getSaleQuery(ctx, filter)
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	WITH sale AS (`+query+`
+	)
+	SELECT i.id, i.item_name, SUM(s.units), SUM(s.revenue), SUM(s.units * i.cost)
+	FROM sale AS s
+	INNER JOIN `+ItemTable+` AS i ON i.id = s.item_id
+	GROUP BY i.id, i.item_name
+	ORDER BY SUM(s.revenue) DESC, i.id
+`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Items - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var report []model.ItemReportRow
+	for rows.Next() {
+		var row model.ItemReportRow
+		err := rows.Scan(&row.ItemID, &row.ItemName, &row.Units, &row.Revenue, &row.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("ReportPostgres - Items - rows.Scan: %w", err)
+		}
+		row.Margin = row.Revenue - row.Cost
+		report = append(report, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Items - rows.Err: %w", err)
+	}
+
+	return report, nil
+}
+
+func (p *ReportPostgres) Customers(
+	ctx context.Context,
+	filter model.ReportFilter,
+) ([]model.CustomerReportRow, error) {
+	query, args := getSaleQuery(ctx, filter)
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	WITH sale AS (`+query+`
+	)
+	SELECT c.id, c.customer_name, SUM(s.units), SUM(s.revenue), SUM(s.units * i.cost)
+	FROM sale AS s
+	INNER JOIN `+ItemTable+` AS i ON i.id = s.item_id
+	INNER JOIN `+CustomerTable+` AS c ON c.id = s.customer_id
+	GROUP BY c.id, c.customer_name
+	ORDER BY SUM(s.revenue) DESC, c.id
+`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Customers - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var report []model.CustomerReportRow
+	for rows.Next() {
+		var row model.CustomerReportRow
+		err := rows.Scan(&row.CustomerID, &row.CustomerName, &row.Units, &row.Revenue, &row.Cost)
+		if err != nil {
+			return nil, fmt.Errorf("ReportPostgres - Customers - rows.Scan: %w", err)
+		}
+		row.Margin = row.Revenue - row.Cost
+		report = append(report, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReportPostgres - Customers - rows.Err: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,137 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type ReturnPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewReturnPostgres(pg *postgres.Postgres) *ReturnPostgres {
+	return &ReturnPostgres{pg: pg}
+}
+
+const ReturnTable = "transaction_return"
+
+func (p *ReturnPostgres) Create(
+	ctx context.Context,
+	ret model.TransactionReturn,
+) (model.TransactionReturn, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the transaction row stays locked so parallel returns can't give back more than was sold
+	transaction, err := lockTransaction(ctx, tx, ret.TransactionID)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - lockTransaction: %w", err)
+	}
+	if transaction.VoidedAt != nil {
+		return model.TransactionReturn{}, model.ErrAlreadyVoided
+	}
+	if !model.IsCharged(transaction.Status) {
+		return model.TransactionReturn{}, model.ErrIllegalTransition
+	}
+	remaining := transaction.Qty - transaction.ReturnedQty
+	if ret.Qty > remaining {
+		return model.TransactionReturn{}, model.ErrReturnExceedsSold
+	}
+	// the last return takes whatever is left so the rounding of earlier returns adds up to the amount
+	ret.Amount = transaction.Amount.MulDiv(ret.Qty, transaction.Qty)
+	fullyReturned := ret.Qty == remaining
+	if fullyReturned {
+		ret.Amount = transaction.Amount - transaction.ReturnedAmount
+	}
+
+	err = tx.QueryRow(
+		ctx, `
+	INSERT INTO `+ReturnTable+`
+	(transaction_id, qty, amount, reason, operator)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at
+`, ret.TransactionID, ret.Qty, ret.Amount, ret.Reason, ret.Operator,
+	).Scan(&ret.ID, &ret.CreatedAt)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - tx.QueryRow: %w", err)
+	}
+	err = creditTransaction(ctx, tx, transaction, model.StockMovement{
+		ItemID:        transaction.ItemID,
+		TransactionID: &transaction.ID,
+		Kind:          model.StockMovementReturn,
+		Qty:           ret.Qty,
+		Reason:        ret.Reason,
+		Operator:      ret.Operator,
+	}, ret.Amount)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - creditTransaction: %w", err)
+	}
+	_, err = tx.Exec(
+		ctx, `
+	UPDATE `+TransactionTable+`
+	SET returned_qty = returned_qty + $2, returned_amount = returned_amount + $3,
+		status = CASE WHEN $4 THEN $5 ELSE status END,
+		refunded_at = CASE WHEN $4 THEN now() ELSE refunded_at END,
+		updated_at = now()
+	WHERE id = $1
+`, ret.TransactionID, ret.Qty, ret.Amount, fullyReturned, model.TransactionStatusRefunded,
+	)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - tx.Exec: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.TransactionReturn{}, fmt.Errorf("ReturnPostgres - Create - tx.Commit: %w", err)
+	}
+	return ret, nil
+}
+
+func (p *ReturnPostgres) GetByTransactionID(
+	ctx context.Context,
+	transactionID int,
+) ([]model.TransactionReturn, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT r.id, r.transaction_id, r.qty, r.amount, r.reason, r.operator, r.created_at
+	FROM `+ReturnTable+` AS r
+	INNER JOIN `+TransactionTable+` AS t ON t.id = r.transaction_id
+	WHERE r.transaction_id = $1 AND t.tenant_id = $2
+	ORDER BY r.id
+`, transactionID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ReturnPostgres - GetByTransactionID - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var returns []model.TransactionReturn
+	for rows.Next() {
+		var ret model.TransactionReturn
+		err := rows.Scan(
+			&ret.ID,
+			&ret.TransactionID,
+			&ret.Qty,
+			&ret.Amount,
+			&ret.Reason,
+			&ret.Operator,
+			&ret.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ReturnPostgres - GetByTransactionID - rows.Scan: %w", err)
+		}
+		returns = append(returns, ret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReturnPostgres - GetByTransactionID - rows.Err: %w", err)
+	}
+
+	return returns, nil
+}
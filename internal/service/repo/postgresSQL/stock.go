@@ -0,0 +1,219 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type StockPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewStockPostgres(pg *postgres.Postgres) *StockPostgres {
+	return &StockPostgres{pg: pg}
+}
+
+const StockMovementTable = "stock_movement"
+
+// insertStockMovement appends a stock history row inside tx, it must be called right after the
+// item stock was changed so that stock_after is taken from the updated row
+func insertStockMovement(ctx context.Context, tx pgx.Tx, movement model.StockMovement) error {
+	_, err := tx.Exec(
+		ctx, `
+	INSERT INTO `+StockMovementTable+`
+	(item_id, transaction_id, order_id, kind, qty, stock_after, reason, operator)
+	SELECT id, $2, $3, $4, $5, stock, $6, $7
+	FROM `+ItemTable+`
+	WHERE id = $1
+`, movement.ItemID, movement.TransactionID, movement.OrderID, movement.Kind, movement.Qty, movement.Reason, movement.Operator,
+	)
+	if err != nil {
+		return fmt.Errorf("insertStockMovement - tx.Exec: %w", err)
+	}
+	return nil
+}
+
+// takeStock decrements the item stock by movement.Qty sold units and records the sale,
+// items without tracked stock are skipped, returns model.ErrInsufficientStock when there is not enough on hand
+func takeStock(ctx context.Context, tx pgx.Tx, movement model.StockMovement) error {
+	var stock *int
+	err := tx.QueryRow(
+		ctx, `SELECT stock FROM `+ItemTable+` WHERE id = $1 AND tenant_id = $2 FOR UPDATE`, movement.ItemID, tenant.ID(ctx),
+	).Scan(&stock)
+	if err != nil {
+		return fmt.Errorf("takeStock - tx.QueryRow: %w", err)
+	}
+	if stock == nil {
+		return nil
+	}
+	if *stock < movement.Qty {
+		return model.ErrInsufficientStock
+	}
+	_, err = tx.Exec(
+		ctx, `UPDATE `+ItemTable+` SET stock = stock - $1, updated_at = now() WHERE id = $2`, movement.Qty, movement.ItemID,
+	)
+	if err != nil {
+		return fmt.Errorf("takeStock - tx.Exec: %w", err)
+	}
+	movement.Kind = model.StockMovementSale
+	movement.Qty = -movement.Qty
+	return insertStockMovement(ctx, tx, movement)
+}
+
+// restoreStock puts sold units of the transaction of movement back on hand. Only the units its sale
+// movements took and earlier voids and returns didn't restore are put back, so an item that was not
+// tracked when it was sold is skipped even if it is tracked now.
+func restoreStock(ctx context.Context, tx pgx.Tx, movement model.StockMovement) error {
+	var taken int
+	err := tx.QueryRow(
+		ctx, `
+	SELECT COALESCE(-SUM(qty), 0)
+	FROM `+StockMovementTable+`
+	WHERE transaction_id = $1 AND item_id = $2 AND kind IN ($3, $4, $5)
+`, movement.TransactionID, movement.ItemID, model.StockMovementSale, model.StockMovementVoid, model.StockMovementReturn,
+	).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("restoreStock - tx.QueryRow: %w", err)
+	}
+	movement.Qty = min(movement.Qty, taken)
+	if movement.Qty <= 0 {
+		return nil
+	}
+	tag, err := tx.Exec(
+		ctx, `UPDATE `+ItemTable+` SET stock = stock + $1, updated_at = now() WHERE id = $2 AND tenant_id = $3 AND stock IS NOT NULL`,
+		movement.Qty, movement.ItemID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("restoreStock - tx.Exec: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	return insertStockMovement(ctx, tx, movement)
+}
+
+func (p *StockPostgres) Receive(ctx context.Context, operation model.StockOperation) (int, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Receive - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// a receipt starts tracking the stock of an item that was not tracked before
+	var stock int
+	err = tx.QueryRow(
+		ctx, `
+	UPDATE `+ItemTable+`
+	SET stock = COALESCE(stock, 0) + $1, updated_at = now()
+	WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL
+	RETURNING stock
+`, operation.Qty, operation.ItemID, tenant.ID(ctx),
+	).Scan(&stock)
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Receive - tx.QueryRow: %w", err)
+	}
+	err = insertStockMovement(ctx, tx, model.StockMovement{
+		ItemID:   operation.ItemID,
+		Kind:     model.StockMovementReceipt,
+		Qty:      operation.Qty,
+		Reason:   operation.Reason,
+		Operator: operation.Operator,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Receive - insertStockMovement: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Receive - tx.Commit: %w", err)
+	}
+	return stock, nil
+}
+
+func (p *StockPostgres) Adjust(ctx context.Context, operation model.StockOperation) (int, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Adjust - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var stock int
+	err = tx.QueryRow(
+		ctx, `
+	UPDATE `+ItemTable+`
+	SET stock = COALESCE(stock, 0) + $1, updated_at = now()
+	WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL AND COALESCE(stock, 0) + $1 >= 0
+	RETURNING stock
+`, operation.Qty, operation.ItemID, tenant.ID(ctx),
+	).Scan(&stock)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, model.ErrInsufficientStock
+		}
+		return 0, fmt.Errorf("StockPostgres - Adjust - tx.QueryRow: %w", err)
+	}
+	err = insertStockMovement(ctx, tx, model.StockMovement{
+		ItemID:   operation.ItemID,
+		Kind:     model.StockMovementAdjustment,
+		Qty:      operation.Qty,
+		Reason:   operation.Reason,
+		Operator: operation.Operator,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Adjust - insertStockMovement: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("StockPostgres - Adjust - tx.Commit: %w", err)
+	}
+	return stock, nil
+}
+
+func (p *StockPostgres) GetMovements(
+	ctx context.Context,
+	itemID, limit, offset int,
+) ([]model.StockMovement, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT m.id, m.item_id, m.transaction_id, m.order_id, m.kind, m.qty, m.stock_after, m.reason, m.operator, m.created_at
+	FROM `+StockMovementTable+` AS m
+	JOIN `+ItemTable+` AS i ON i.id = m.item_id
+	WHERE m.item_id = $1 AND i.tenant_id = $2
+	ORDER BY m.id`+getLimitAndOffset(limit, offset), itemID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("StockPostgres - GetMovements - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []model.StockMovement
+	for rows.Next() {
+		var movement model.StockMovement
+		err := rows.Scan(
+			&movement.ID,
+			&movement.ItemID,
+			&movement.TransactionID,
+			&movement.OrderID,
+			&movement.Kind,
+			&movement.Qty,
+			&movement.StockAfter,
+			&movement.Reason,
+			&movement.Operator,
+			&movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("StockPostgres - GetMovements - rows.Scan: %w", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("StockPostgres - GetMovements - rows.Err: %w", err)
+	}
+
+	return movements, nil
+}
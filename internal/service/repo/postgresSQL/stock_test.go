@@ -0,0 +1,47 @@
+package postgresSQL
+
+import (
+	"testing"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+// an item sold before its stock was tracked gets nothing back when the sale is voided
+func TestVoidRestoresOnlySoldStock(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	transactions := NewTransactionPostgres(pg)
+	customerID := testCustomer(t, ctx, pg, 1000)
+	itemID := testItem(t, ctx, pg, 100)
+
+	untracked, err := transactions.Create(ctx, model.Transaction{
+		CustomerID: customerID, ItemID: itemID, Qty: 2, Price: 100, Amount: 200,
+		Status: model.TransactionStatusPaid, CreatedBy: "test",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := NewStockPostgres(pg).Receive(ctx, model.StockOperation{ItemID: itemID, Qty: 5, Reason: "test"}); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	tracked, err := transactions.Create(ctx, model.Transaction{
+		CustomerID: customerID, ItemID: itemID, Qty: 3, Price: 100, Amount: 300,
+		Status: model.TransactionStatusPaid, CreatedBy: "test",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, id := range []int{untracked, tracked} {
+		if err := transactions.Delete(ctx, id, "test", "test"); err != nil {
+			t.Fatalf("Delete %d: %v", id, err)
+		}
+	}
+	item, err := NewItemPostgres(pg).GetByID(ctx, itemID)
+	if err != nil {
+		t.Fatalf("ItemPostgres.GetByID: %v", err)
+	}
+	if item.Stock == nil || *item.Stock != 5 {
+		t.Errorf("stock is %v, want the 5 received units", item.Stock)
+	}
+}
@@ -0,0 +1,94 @@
+package postgresSQL
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+type TenantPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewTenantPostgres(pg *postgres.Postgres) *TenantPostgres {
+	return &TenantPostgres{pg: pg}
+}
+
+const TenantTable = "tenant"
+
+const tenantColumns = `id, slug, name, created_at`
+
+func scanTenant(row pgx.Row) (model.Tenant, error) {
+	var tenant model.Tenant
+	err := row.Scan(
+		&tenant.ID,
+		&tenant.Slug,
+		&tenant.Name,
+		&tenant.CreatedAt,
+	)
+	return tenant, err
+}
+
+func (p *TenantPostgres) Create(ctx context.Context, tenant model.Tenant) (model.Tenant, error) {
+	tenant, err := scanTenant(p.pg.Pool.QueryRow(
+		ctx, `
+	INSERT INTO `+TenantTable+`
+	(slug, name)
+	VALUES ($1, $2)
+	RETURNING `+tenantColumns,
+		tenant.Slug, tenant.Name,
+	))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return model.Tenant{}, model.ErrTenantSlugTaken
+		}
+		return model.Tenant{}, fmt.Errorf("TenantPostgres - Create - p.pg.Pool.QueryRow: %w", err)
+	}
+	return tenant, nil
+}
+
+func (p *TenantPostgres) GetByID(ctx context.Context, id int) (model.Tenant, error) {
+	tenant, err := scanTenant(p.pg.Pool.QueryRow(
+		ctx, `
+	SELECT `+tenantColumns+`
+	FROM `+TenantTable+`
+	WHERE id = $1
+`, id,
+	))
+	if err != nil {
+		return model.Tenant{}, fmt.Errorf("TenantPostgres - GetByID - p.pg.Pool.QueryRow: %w", err)
+	}
+	return tenant, nil
+}
+
+func (p *TenantPostgres) GetAll(ctx context.Context) ([]model.Tenant, error) {
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	SELECT `+tenantColumns+`
+	FROM `+TenantTable+`
+	ORDER BY id
+`)
+	if err != nil {
+		return nil, fmt.Errorf("TenantPostgres - GetAll - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []model.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("TenantPostgres - GetAll - rows.Scan: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("TenantPostgres - GetAll - rows.Err: %w", err)
+	}
+	return tenants, nil
+}
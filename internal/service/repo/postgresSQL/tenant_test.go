@@ -0,0 +1,144 @@
+package postgresSQL
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+// The tenant tests create a row in tenant b and check that tenant a can't see or change it
+
+func TestItemTenantIsolation(t *testing.T) {
+	pg := testPostgres(t)
+	a, b := testTenant(t, pg), testTenant(t, pg)
+	items := NewItemPostgres(pg)
+	id, err := items.Create(b, model.Item{ItemName: "item", Cost: 100, Price: 200, CreatedBy: "test"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := items.GetByID(a, id); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetByID: got %v, want pgx.ErrNoRows", err)
+	}
+	list, err := items.GetAll(a, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("GetAll returned %d items of another tenant", len(list))
+	}
+	_, err = items.Update(a, model.Item{ID: id, ItemName: "changed", Cost: 1, Price: 1, UpdatedBy: "test"})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Update: got %v, want pgx.ErrNoRows", err)
+	}
+	if err := items.Delete(a, id, "test"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	item, err := items.GetByID(b, id)
+	if err != nil {
+		t.Fatalf("GetByID of the owner: %v", err)
+	}
+	if item.ItemName != "item" || item.Price != 200 {
+		t.Errorf("item was changed by another tenant: %+v", item)
+	}
+}
+
+func TestCustomerTenantIsolation(t *testing.T) {
+	pg := testPostgres(t)
+	a, b := testTenant(t, pg), testTenant(t, pg)
+	customers := NewCustomerPostgres(pg)
+	id := testCustomer(t, b, pg, 1000)
+
+	if _, err := customers.GetByID(a, id); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetByID: got %v, want pgx.ErrNoRows", err)
+	}
+	list, err := customers.GetAll(a, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("GetAll returned %d customers of another tenant", len(list))
+	}
+	_, err = customers.Update(a, model.Customer{ID: id, Name: "changed", Balance: 1, UpdatedBy: "test"})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Update: got %v, want pgx.ErrNoRows", err)
+	}
+	if err := customers.Delete(a, id, "test"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	customer, err := customers.GetByID(b, id)
+	if err != nil {
+		t.Fatalf("GetByID of the owner: %v", err)
+	}
+	if customer.Name != "customer" || customer.Balance != 1000 {
+		t.Errorf("customer was changed by another tenant: %+v", customer)
+	}
+}
+
+func TestTransactionTenantIsolation(t *testing.T) {
+	pg := testPostgres(t)
+	a, b := testTenant(t, pg), testTenant(t, pg)
+	transactions := NewTransactionPostgres(pg)
+	customerID := testCustomer(t, b, pg, 1000)
+	itemID, err := NewItemPostgres(pg).Create(b, model.Item{ItemName: "item", Cost: 100, Price: 200, CreatedBy: "test"})
+	if err != nil {
+		t.Fatalf("ItemPostgres.Create: %v", err)
+	}
+	id, err := transactions.Create(b, model.Transaction{
+		CustomerID: customerID,
+		ItemID:     itemID,
+		Qty:        1,
+		Price:      200,
+		Amount:     200,
+		Status:     model.TransactionStatusPaid,
+		CreatedBy:  "test",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := transactions.GetByID(a, id); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetByID: got %v, want pgx.ErrNoRows", err)
+	}
+	list, err := transactions.GetAll(a, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("GetAll returned %d transactions of another tenant", len(list))
+	}
+	_, err = transactions.Update(a, model.Transaction{
+		ID:         id,
+		CustomerID: customerID,
+		ItemID:     itemID,
+		Qty:        2,
+		Price:      200,
+		Amount:     400,
+		UpdatedBy:  "test",
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Update: got %v, want pgx.ErrNoRows", err)
+	}
+	if err := transactions.Delete(a, id, "test", "test"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Delete: got %v, want pgx.ErrNoRows", err)
+	}
+
+	transaction, err := transactions.GetByID(b, id)
+	if err != nil {
+		t.Fatalf("GetByID of the owner: %v", err)
+	}
+	if transaction.Qty != 1 || transaction.VoidedAt != nil || transaction.Status != model.TransactionStatusPaid {
+		t.Errorf("transaction was changed by another tenant: %+v", transaction)
+	}
+	customer, err := NewCustomerPostgres(pg).GetByID(b, customerID)
+	if err != nil {
+		t.Fatalf("CustomerPostgres.GetByID: %v", err)
+	}
+	if customer.Balance != 800 {
+		t.Errorf("customer balance is %v, want 8.00", customer.Balance)
+	}
+}
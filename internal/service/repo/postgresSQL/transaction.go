@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
 )
 
 type TransactionPostgres struct {
@@ -20,93 +22,260 @@ func NewTransactionPostgres(pg *postgres.Postgres) *TransactionPostgres {
 
 const TransactionTable = "transaction"
 
+const transactionColumns = `id, tenant_id, customer_id, item_id, qty, price, amount, status, created_at, updated_at, deleted_at,
+	voided_at, void_reason, price_override_reason, paid_at, fulfilled_at, cancelled_at, refunded_at,
+	returned_qty, returned_amount, created_by, updated_by`
+
+// transactionStatusColumns holds the timestamp column set when a transaction enters a status
+var transactionStatusColumns = map[string]string{
+	model.TransactionStatusPaid:      "paid_at",
+	model.TransactionStatusFulfilled: "fulfilled_at",
+	model.TransactionStatusCancelled: "cancelled_at",
+	model.TransactionStatusRefunded:  "refunded_at",
+}
+
+func scanTransaction(row pgx.Row) (model.Transaction, error) {
+	var transaction model.Transaction
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.TenantID,
+		&transaction.CustomerID,
+		&transaction.ItemID,
+		&transaction.Qty,
+		&transaction.Price,
+		&transaction.Amount,
+		&transaction.Status,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+		&transaction.DeletedAt,
+		&transaction.VoidedAt,
+		&transaction.VoidReason,
+		&transaction.PriceOverrideReason,
+		&transaction.PaidAt,
+		&transaction.FulfilledAt,
+		&transaction.CancelledAt,
+		&transaction.RefundedAt,
+		&transaction.ReturnedQty,
+		&transaction.ReturnedAmount,
+		&transaction.CreatedBy,
+		&transaction.UpdatedBy,
+	)
+	return transaction, err
+}
+
 func (p *TransactionPostgres) Create(
 	ctx context.Context,
 	transaction model.Transaction,
 ) (int, error) {
 	// return id
 	var id int
-	tx, err := p.pg.Pool.Begin(ctx)
+	tx, err := conn(ctx, p.pg).Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("TransactionPostgres - Create - p.pg.Pool.Begin: %w", err)
+		return 0, fmt.Errorf("TransactionPostgres - Create - conn.Begin: %w", err)
 	}
-	// need to minutes the amount from the customer balance in customer table by customer_id  and then insert the transaction into transaction table
-	_, err = tx.Exec(
+	defer tx.Rollback(ctx)
+
+	if transaction.Status == "" {
+		transaction.Status = model.TransactionStatusPaid
+	}
+	err = tx.QueryRow(
+		ctx, `
+	INSERT INTO `+TransactionTable+`
+	(customer_id, item_id, qty, price, amount, price_override_reason, status, paid_at, created_at, updated_at, deleted_at,
+	created_by, updated_by, tenant_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $8 THEN now() END, now(), now(), null, $9, $9, $10)
+  RETURNING id
+`, transaction.CustomerID, transaction.ItemID, transaction.Qty, transaction.Price, transaction.Amount, transaction.PriceOverrideReason,
+		transaction.Status, transaction.Status == model.TransactionStatusPaid, transaction.CreatedBy, tenant.ID(ctx),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("TransactionPostgres - Create - tx.QueryRow: %w", err)
+	}
+	// a pending transaction is charged when it is paid
+	if transaction.Status == model.TransactionStatusPaid {
+		transaction.ID = id
+		err = chargeTransaction(ctx, tx, transaction, "purchase", transaction.CreatedBy)
+		if err != nil {
+			return 0, fmt.Errorf("TransactionPostgres - Create - chargeTransaction: %w", err)
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("TransactionPostgres - Create - tx.Commit: %w", err)
+	}
+
+	return id, nil
+}
+
+// debitCustomer takes amount from the customer balance only if it is enough and returns the new balance.
+// It returns model.ErrInsufficientBalance when the balance is too low and
+// pgx.ErrNoRows when the customer doesn't exist in the tenant.
+func debitCustomer(ctx context.Context, tx pgx.Tx, customerID int, amount model.Money) (model.Money, error) {
+	var balance model.Money
+	err := tx.QueryRow(
 		ctx, `
 	UPDATE customer
-	SET balance = balance - $1
-	WHERE id = $2
-`, transaction.Amount, transaction.CustomerID,
-	)
+	SET balance = balance - $1, updated_at = now()
+	WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL AND balance >= $1
+	RETURNING balance
+`, amount, customerID, tenant.ID(ctx),
+	).Scan(&balance)
+	if err == nil {
+		return balance, nil
+	}
+	if err != pgx.ErrNoRows {
+		return 0, err
+	}
+	// nothing was updated, either the customer is missing or the balance is too low
+	var exists bool
+	err = tx.QueryRow(
+		ctx, `
+	SELECT EXISTS (SELECT 1 FROM customer WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL)
+`, customerID, tenant.ID(ctx),
+	).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, pgx.ErrNoRows
+	}
+	return 0, model.ErrInsufficientBalance
+}
+
+// chargeTransaction debits the customer and takes the sold units from stock.
+// The balance check is part of the update and the customer row stays locked until commit,
+// so parallel purchases can't overspend.
+func chargeTransaction(ctx context.Context, tx pgx.Tx, transaction model.Transaction, reason, operator string) error {
+	_, err := debitCustomer(ctx, tx, transaction.CustomerID, transaction.Amount)
 	if err != nil {
-		tx.Rollback(ctx)
-		return 0, fmt.Errorf("TransactionPostgres - Create - tx.Pool.Exec: %w", err)
+		return fmt.Errorf("debitCustomer: %w", err)
 	}
-	ID := tx.QueryRow(
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID:    transaction.CustomerID,
+		TransactionID: &transaction.ID,
+		Kind:          model.BalanceEntryDebit,
+		Amount:        -transaction.Amount,
+		Reason:        reason,
+		Operator:      operator,
+	})
+	if err != nil {
+		return fmt.Errorf("insertBalanceEntry: %w", err)
+	}
+	err = takeStock(ctx, tx, model.StockMovement{
+		ItemID:        transaction.ItemID,
+		TransactionID: &transaction.ID,
+		Qty:           transaction.Qty,
+		Operator:      operator,
+	})
+	if err != nil {
+		return fmt.Errorf("takeStock: %w", err)
+	}
+	return nil
+}
+
+// refundTransaction gives the amount back to the customer and puts the units back on hand,
+// units already returned were refunded by their return and are left out
+func refundTransaction(ctx context.Context, tx pgx.Tx, transaction model.Transaction, reason, operator string) error {
+	qty := transaction.Qty - transaction.ReturnedQty
+	if qty == 0 {
+		return nil
+	}
+	return creditTransaction(ctx, tx, transaction, model.StockMovement{
+		ItemID:        transaction.ItemID,
+		TransactionID: &transaction.ID,
+		Kind:          model.StockMovementVoid,
+		Qty:           qty,
+		Reason:        reason,
+		Operator:      operator,
+	}, transaction.Amount-transaction.ReturnedAmount)
+}
+
+// creditTransaction credits amount to the customer of the transaction and restores the movement units
+func creditTransaction(
+	ctx context.Context,
+	tx pgx.Tx,
+	transaction model.Transaction,
+	movement model.StockMovement,
+	amount model.Money,
+) error {
+	_, err := tx.Exec(
 		ctx, `
-	INSERT INTO `+TransactionTable+`
-	(customer_id, item_id, qty, price, amount, created_at, updated_at, deleted_at)
-	VALUES ($1, $2, $3, $4, $5, now(), now(), null)
-  RETURNING id
-`, transaction.CustomerID, transaction.ItemID, transaction.Qty, transaction.Price, transaction.Amount,
+	UPDATE customer
+	SET balance = balance + $1, updated_at = now()
+	WHERE id = $2 AND tenant_id = $3
+`, amount, transaction.CustomerID, tenant.ID(ctx),
 	)
 	if err != nil {
-		return 0, fmt.Errorf("TransactionPostgres - Create - tx.Pool.Exec: %w", err)
+		return fmt.Errorf("tx.Exec: %w", err)
 	}
-	err = ID.Scan(&id)
+	err = insertBalanceEntry(ctx, tx, model.BalanceEntry{
+		CustomerID:    transaction.CustomerID,
+		TransactionID: &transaction.ID,
+		Kind:          model.BalanceEntryRefund,
+		Amount:        amount,
+		Reason:        movement.Reason,
+		Operator:      movement.Operator,
+	})
 	if err != nil {
-		tx.Rollback(ctx)
-		return 0, fmt.Errorf("TransactionPostgres - Create - ID.Scan: %w", err)
+		return fmt.Errorf("insertBalanceEntry: %w", err)
 	}
-	err = tx.Commit(ctx)
+	err = restoreStock(ctx, tx, movement)
 	if err != nil {
-		tx.Rollback(ctx)
-		return 0, fmt.Errorf("TransactionPostgres - Create - tx.Pool.Commit: %w", err)
+		return fmt.Errorf("restoreStock: %w", err)
 	}
+	return nil
+}
 
-	return id, nil
+// lockTransaction reads the transaction and locks its row until the end of tx
+func lockTransaction(ctx context.Context, tx db, id int) (model.Transaction, error) {
+	return scanTransaction(tx.QueryRow(
+		ctx, `
+	SELECT `+transactionColumns+`
+	FROM `+TransactionTable+`
+	WHERE id = $1 AND tenant_id = $2
+	FOR UPDATE
+`, id, tenant.ID(ctx),
+	))
 }
 
 func (p *TransactionPostgres) IDExists(ctx context.Context, id int) (bool, error) {
 	// if this id exist
 	var exists bool
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, `
 	SELECT EXISTS (
 		SELECT 1
 		FROM `+TransactionTable+`
-		WHERE id = $1
+		WHERE id = $1 AND tenant_id = $2
 	)
-`, id,
+`, id, tenant.ID(ctx),
 	).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("TransactionPostgres - IDExist - p.pg.Pool.QueryRow: %w", err)
+		return false, fmt.Errorf("TransactionPostgres - IDExist - conn.QueryRow: %w", err)
 	}
 	return exists, nil
 }
 
+// GetForUpdate reads the transaction, a voided one too, and locks it until the transaction of ctx ends
+func (p *TransactionPostgres) GetForUpdate(ctx context.Context, id int) (model.Transaction, error) {
+	transaction, err := lockTransaction(ctx, conn(ctx, p.pg), id)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - GetForUpdate - lockTransaction: %w", err)
+	}
+	return transaction, nil
+}
+
 func (p *TransactionPostgres) GetByID(ctx context.Context, id int) (model.Transaction, error) {
-	var transaction model.Transaction
-	err := p.pg.Pool.QueryRow(
+	transaction, err := scanTransaction(conn(ctx, p.pg).QueryRow(
 		ctx, `
-	SELECT id, customer_id, item_id, qty, price, amount, created_at, updated_at, deleted_at
+	SELECT `+transactionColumns+`
 	FROM `+TransactionTable+`
-	WHERE id = $1 AND deleted_at IS NULL
-`, id,
-	).Scan(
-		&transaction.ID,
-		&transaction.CustomerID,
-		&transaction.ItemID,
-		&transaction.Qty,
-		&transaction.Price,
-		&transaction.Amount,
-		&transaction.CreatedAt,
-		&transaction.UpdatedAt,
-		&transaction.DeletedAt,
-	)
+	WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+`, id, tenant.ID(ctx),
+	))
 	if err != nil {
 		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - GetByID - p.pg.Pool.QueryRow: %w",
+			"TransactionPostgres - GetByID - conn.QueryRow: %w",
 			err,
 		)
 	}
@@ -117,33 +286,20 @@ func (p *TransactionPostgres) GetAll(
 	ctx context.Context,
 	limit, offset int,
 ) ([]model.Transaction, error) {
-	if limit == 0 {
-		limit = -1
-	}
-	rows, err := p.pg.Pool.Query(
+	rows, err := conn(ctx, p.pg).Query(
 		ctx, `
-	SELECT id, customer_id, item_id, qty, price, amount, created_at, updated_at, deleted_at
-	FROM `+TransactionTable+" WHERE deleted_at IS NULL"+getLimitAndOffset(limit, offset),
+	SELECT `+transactionColumns+`
+	FROM `+TransactionTable+" WHERE tenant_id = $1 AND deleted_at IS NULL ORDER BY id"+getLimitAndOffset(limit, offset),
+		tenant.ID(ctx),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("TransactionPostgres - GetAll - p.pg.Pool.Query: %w", err)
+		return nil, fmt.Errorf("TransactionPostgres - GetAll - conn.Query: %w", err)
 	}
 	defer rows.Close()
 
 	var transactions []model.Transaction
 	for rows.Next() {
-		var transaction model.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.CustomerID,
-			&transaction.ItemID,
-			&transaction.Qty,
-			&transaction.Price,
-			&transaction.Amount,
-			&transaction.CreatedAt,
-			&transaction.UpdatedAt,
-			&transaction.DeletedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, fmt.Errorf("TransactionPostgres - GetAll - rows.Scan: %w", err)
 		}
@@ -157,75 +313,110 @@ func (p *TransactionPostgres) GetAll(
 	return transactions, nil
 }
 
+// GetAfter returns up to limit transactions with an id greater than afterID, ordered by id
+func (p *TransactionPostgres) GetAfter(
+	ctx context.Context,
+	afterID, limit int,
+) ([]model.Transaction, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, `
+	SELECT `+transactionColumns+`
+	FROM `+TransactionTable+`
+	WHERE tenant_id = $3 AND deleted_at IS NULL AND id > $1
+	ORDER BY id
+	LIMIT $2
+`, afterID, limit, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionPostgres - GetAfter - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("TransactionPostgres - GetAfter - rows.Scan: %w", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("TransactionPostgres - GetAfter - rows.Err: %w", err)
+	}
+
+	return transactions, nil
+}
+
 func (p *TransactionPostgres) Update(
 	ctx context.Context,
 	transaction model.Transaction,
 ) (model.Transaction, error) {
-	// at first i need to get the transaction from db and add it's amount to the customer and then minus it with struct's amount and update transaction it self
-	tx, err := p.pg.Pool.Begin(ctx)
+	// a paid transaction is refunded with its previous values and charged again with the new ones,
+	// a pending one is only rewritten
+	tx, err := conn(ctx, p.pg).Begin(ctx)
 	if err != nil {
 		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - Update - p.pg.Pool.Begin: %w",
+			"TransactionPostgres - Update - conn.Begin: %w",
 			err,
 		)
 	}
-	_, err = tx.Exec(
-		ctx, `
-        WITH retrieved_data AS (
-            SELECT amount, customer_id 
-            FROM transaction
-            WHERE id = $1 
-        )
-        UPDATE customer
-        SET balance = balance + retrieved_data.amount
-        FROM retrieved_data
-        WHERE id = retrieved_data.customer_id
-    `, transaction.ID,
-	)
+	defer tx.Rollback(ctx)
 
+	previous, err := lockTransaction(ctx, tx, transaction.ID)
 	if err != nil {
-		tx.Rollback(ctx)
 		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - Update - tx.Pool.Exec: %w",
+			"TransactionPostgres - Update - lockTransaction: %w",
 			err,
 		)
 	}
-
-	_, err = tx.Exec(ctx,
-		`
-	UPDATE customer 
-	set balance = balance - $1
-	WHERE id = $2
-	`, transaction.Amount, transaction.CustomerID,
-	)
-
-	if err != nil {
-		tx.Rollback(ctx)
-		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - Update - tx.Pool.Exec: %w",
-			err,
-		)
+	if previous.VoidedAt != nil {
+		return model.Transaction{}, model.ErrAlreadyVoided
+	}
+	// returns were priced from the current row, rewriting it would break their amounts
+	if previous.ReturnedQty > 0 {
+		return model.Transaction{}, model.ErrHasReturns
+	}
+	switch previous.Status {
+	case model.TransactionStatusPending:
+	case model.TransactionStatusPaid:
+		err = refundTransaction(ctx, tx, previous, "transaction updated", transaction.UpdatedBy)
+		if err != nil {
+			return model.Transaction{}, fmt.Errorf(
+				"TransactionPostgres - Update - refundTransaction: %w",
+				err,
+			)
+		}
+		err = chargeTransaction(ctx, tx, transaction, "transaction updated", transaction.UpdatedBy)
+		if err != nil {
+			return model.Transaction{}, fmt.Errorf(
+				"TransactionPostgres - Update - chargeTransaction: %w",
+				err,
+			)
+		}
+	default:
+		return model.Transaction{}, model.ErrIllegalTransition
 	}
 
 	_, err = tx.Exec(
 		ctx, `
 	UPDATE `+TransactionTable+`
-	SET customer_id = $1, item_id = $2, qty = $3, price = $4, amount = $5, updated_at = now()
-	WHERE id = $6
-`, transaction.CustomerID, transaction.ItemID, transaction.Qty, transaction.Price, transaction.Amount, transaction.ID,
+	SET customer_id = $1, item_id = $2, qty = $3, price = $4, amount = $5, price_override_reason = $6, updated_by = $7,
+		updated_at = now()
+	WHERE id = $8 AND tenant_id = $9
+`, transaction.CustomerID, transaction.ItemID, transaction.Qty, transaction.Price, transaction.Amount, transaction.PriceOverrideReason,
+		transaction.UpdatedBy, transaction.ID, tenant.ID(ctx),
 	)
 	if err != nil {
-		tx.Rollback(ctx)
 		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - Update - tx.Pool.Exec: %w",
+			"TransactionPostgres - Update - tx.Exec: %w",
 			err,
 		)
 	}
 	err = tx.Commit(ctx)
 	if err != nil {
-		tx.Rollback(ctx)
 		return model.Transaction{}, fmt.Errorf(
-			"TransactionPostgres - Update - tx.Pool.Commit: %w",
+			"TransactionPostgres - Update - tx.Commit: %w",
 			err,
 		)
 	}
@@ -240,17 +431,120 @@ func (p *TransactionPostgres) Update(
 	return transaction, nil
 }
 
-func (p *TransactionPostgres) Delete(ctx context.Context, id int) error {
-	// set deleted time to time now
-	_, err := p.pg.Pool.Exec(
+func (p *TransactionPostgres) Transition(
+	ctx context.Context,
+	id int,
+	status, reason, actor string,
+) (model.Transaction, error) {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := lockTransaction(ctx, tx, id)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - lockTransaction: %w", err)
+	}
+	// checked again under the row lock, the service check may be stale
+	if transaction.VoidedAt != nil || !model.CanTransition(transaction.Status, status) {
+		return model.Transaction{}, model.ErrIllegalTransition
+	}
+	err = p.applyTransition(ctx, tx, transaction, status, reason, actor)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - p.applyTransition: %w", err)
+	}
+	_, err = tx.Exec(
+		ctx, fmt.Sprintf(`
+	UPDATE %s
+	SET status = $2, %s = now(), updated_by = $3, updated_at = now()
+	WHERE id = $1
+`, TransactionTable, transactionStatusColumns[status]), id, status, actor,
+	)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - tx.Exec: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - tx.Commit: %w", err)
+	}
+	transaction, err = p.GetByID(ctx, id)
+	if err != nil {
+		return model.Transaction{}, fmt.Errorf("TransactionPostgres - Transition - p.GetByID: %w", err)
+	}
+	return transaction, nil
+}
+
+// applyTransition moves money and stock for a status change of a locked transaction made by actor
+func (p *TransactionPostgres) applyTransition(
+	ctx context.Context,
+	tx pgx.Tx,
+	transaction model.Transaction,
+	status, reason, actor string,
+) error {
+	switch {
+	case status == model.TransactionStatusPaid:
+		return chargeTransaction(ctx, tx, transaction, "transaction paid", actor)
+	case (status == model.TransactionStatusCancelled || status == model.TransactionStatusRefunded) &&
+		model.IsCharged(transaction.Status):
+		return refundTransaction(ctx, tx, transaction, reason, actor)
+	}
+	return nil
+}
+
+func (p *TransactionPostgres) Delete(ctx context.Context, id int, reason, actor string) error {
+	// void the transaction: refund the customer and mark the row voided and deleted in one go
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("TransactionPostgres - Delete - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := lockTransaction(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("TransactionPostgres - Delete - lockTransaction: %w", err)
+	}
+	if transaction.VoidedAt != nil {
+		return model.ErrAlreadyVoided
+	}
+
+	// a pending or paid sale is cancelled, a fulfilled one is refunded, final ones are only hidden
+	status := transaction.Status
+	switch transaction.Status {
+	case model.TransactionStatusPending, model.TransactionStatusPaid:
+		status = model.TransactionStatusCancelled
+	case model.TransactionStatusFulfilled:
+		status = model.TransactionStatusRefunded
+	}
+	if status != transaction.Status {
+		err = p.applyTransition(ctx, tx, transaction, status, reason, actor)
+		if err != nil {
+			return fmt.Errorf("TransactionPostgres - Delete - p.applyTransition: %w", err)
+		}
+		_, err = tx.Exec(
+			ctx, fmt.Sprintf(`
+	UPDATE %s
+	SET status = $2, %s = now()
+	WHERE id = $1
+`, TransactionTable, transactionStatusColumns[status]), id, status,
+		)
+		if err != nil {
+			return fmt.Errorf("TransactionPostgres - Delete - tx.Exec: %w", err)
+		}
+	}
+	_, err = tx.Exec(
 		ctx, `
-	UPDATE `+TransactionTable+
-			` SET deleted_at = now()
+	UPDATE `+TransactionTable+`
+	SET voided_at = now(), void_reason = $2, deleted_at = now(), updated_by = $3, updated_at = now()
 	WHERE id = $1
-`, id,
+`, id, reason, actor,
 	)
 	if err != nil {
-		return fmt.Errorf("TransactionPostgres - Delete - p.pg.Pool.Exec: %w", err)
+		return fmt.Errorf("TransactionPostgres - Delete - tx.Exec: %w", err)
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("TransactionPostgres - Delete - tx.Commit: %w", err)
 	}
 	return nil
 }
@@ -258,17 +552,17 @@ func (p *TransactionPostgres) Delete(ctx context.Context, id int) error {
 func (p *TransactionPostgres) GetAllTransactionViews(ctx context.Context, limit, offset int) (
 	[]model.TransactionView, error,
 ) {
-	rows, err := p.pg.Pool.Query(
+	rows, err := conn(ctx, p.pg).Query(
 		ctx, `
-	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.created_at, t.updated_at, t.deleted_at
+	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.status, t.created_at, t.updated_at, t.deleted_at
 	FROM `+TransactionTable+` AS t
 	INNER JOIN customer AS c ON t.customer_id = c.id
-	INNER JOIN item AS i ON t.item_id = i.id`+` WHERE t.deleted_at IS NULL`+
-			getLimitAndOffset(limit, offset),
+	INNER JOIN item AS i ON t.item_id = i.id`+` WHERE t.tenant_id = $1 AND t.deleted_at IS NULL ORDER BY t.id`+
+			getLimitAndOffset(limit, offset), tenant.ID(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf(
-			"TransactionPostgres - GetAllTransactionViews - p.pg.Pool.Query: %w",
+			"TransactionPostgres - GetAllTransactionViews - conn.Query: %w",
 			err,
 		)
 	}
@@ -286,6 +580,7 @@ func (p *TransactionPostgres) GetAllTransactionViews(ctx context.Context, limit,
 			&transactionView.Qty,
 			&transactionView.Price,
 			&transactionView.Amount,
+			&transactionView.Status,
 			&transactionView.CreatedAt,
 			&transactionView.UpdatedAt,
 			&transactionView.DeletedAt,
@@ -306,19 +601,52 @@ func (p *TransactionPostgres) GetAllTransactionViews(ctx context.Context, limit,
 	return transactionViews, nil
 }
 
+// GetTransactionViewsAfter returns up to limit transaction views with an id greater than afterID, ordered by id
+func (p *TransactionPostgres) GetTransactionViewsAfter(
+	ctx context.Context,
+	afterID, limit int,
+) ([]model.TransactionView, error) {
+	rows, err := conn(ctx, p.pg).Query(
+		ctx, transactionViewQuery+`
+	WHERE t.tenant_id = $3 AND t.deleted_at IS NULL AND t.id > $1
+	ORDER BY t.id
+	LIMIT $2
+`, afterID, limit, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("TransactionPostgres - GetTransactionViewsAfter - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var transactionViews []model.TransactionView
+	for rows.Next() {
+		transactionView, err := scanTransactionView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("TransactionPostgres - GetTransactionViewsAfter - rows.Scan: %w", err)
+		}
+		transactionViews = append(transactionViews, transactionView)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("TransactionPostgres - GetTransactionViewsAfter - rows.Err: %w", err)
+	}
+
+	return transactionViews, nil
+}
+
 func (p *TransactionPostgres) GetByTransactionID(
 	ctx context.Context,
 	id int,
 ) (model.TransactionView, error) {
 	var transactionView model.TransactionView
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, `
-	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.created_at, t.updated_at, t.deleted_at
+	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.status, t.created_at, t.updated_at, t.deleted_at
 	FROM `+TransactionTable+` AS t
 	INNER JOIN customer AS c ON t.customer_id = c.id
 	INNER JOIN item AS i ON t.item_id = i.id
-	WHERE t.id = $1 AND t.deleted_at IS NULL
-`, id,
+	WHERE t.id = $1 AND t.tenant_id = $2 AND t.deleted_at IS NULL
+`, id, tenant.ID(ctx),
 	).Scan(
 		&transactionView.ID,
 		&transactionView.CustomerID,
@@ -328,13 +656,14 @@ func (p *TransactionPostgres) GetByTransactionID(
 		&transactionView.Qty,
 		&transactionView.Price,
 		&transactionView.Amount,
+		&transactionView.Status,
 		&transactionView.CreatedAt,
 		&transactionView.UpdatedAt,
 		&transactionView.DeletedAt,
 	)
 	if err != nil {
 		return model.TransactionView{}, fmt.Errorf(
-			"TransactionPostgres - GetByTransactionID - p.pg.Pool.QueryRow: %w", err,
+			"TransactionPostgres - GetByTransactionID - conn.QueryRow: %w", err,
 		)
 	}
 	return transactionView, nil
@@ -345,14 +674,14 @@ func (p *TransactionPostgres) GetByCustomerName(
 	name string,
 ) (model.TransactionView, error) {
 	var transactionView model.TransactionView
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, `
-	SELECT t.id, t.customer_id, c.name, t.item_id, i.name, t.qty, t.price, t.amount, t.created_at, t.updated_at, t.deleted_at
+	SELECT t.id, t.customer_id, c.name, t.item_id, i.name, t.qty, t.price, t.amount, t.status, t.created_at, t.updated_at, t.deleted_at
 	FROM `+TransactionTable+` AS t
 	INNER JOIN customer AS c ON t.customer_id = c.id
 	INNER JOIN item AS i ON t.item_id = i.id
-	WHERE c.name = $1
-`, name,
+	WHERE c.name = $1 AND t.tenant_id = $2
+`, name, tenant.ID(ctx),
 	).Scan(
 		&transactionView.ID,
 		&transactionView.CustomerID,
@@ -362,13 +691,14 @@ func (p *TransactionPostgres) GetByCustomerName(
 		&transactionView.Qty,
 		&transactionView.Price,
 		&transactionView.Amount,
+		&transactionView.Status,
 		&transactionView.CreatedAt,
 		&transactionView.UpdatedAt,
 		&transactionView.DeletedAt,
 	)
 	if err != nil {
 		return model.TransactionView{}, fmt.Errorf(
-			"TransactionPostgres - GetByCustomerName - p.pg.Pool.QueryRow: %w", err,
+			"TransactionPostgres - GetByCustomerName - conn.QueryRow: %w", err,
 		)
 	}
 	return transactionView, nil
@@ -379,14 +709,14 @@ func (p *TransactionPostgres) GetByItemName(
 	name string,
 ) (model.TransactionView, error) {
 	var transactionView model.TransactionView
-	err := p.pg.Pool.QueryRow(
+	err := conn(ctx, p.pg).QueryRow(
 		ctx, `
-	SELECT t.id, t.customer_id, c.name, t.item_id, i.name, t.qty, t.price, t.amount, t.created_at, t.updated_at, t.deleted_at
+	SELECT t.id, t.customer_id, c.name, t.item_id, i.name, t.qty, t.price, t.amount, t.status, t.created_at, t.updated_at, t.deleted_at
 	FROM `+TransactionTable+` AS t
 	INNER JOIN customer AS c ON t.customer_id = c.id
 	INNER JOIN item AS i ON t.item_id = i.id
-	WHERE i.name = $1
-`, name,
+	WHERE i.name = $1 AND t.tenant_id = $2
+`, name, tenant.ID(ctx),
 	).Scan(
 		&transactionView.ID,
 		&transactionView.CustomerID,
@@ -396,13 +726,14 @@ func (p *TransactionPostgres) GetByItemName(
 		&transactionView.Qty,
 		&transactionView.Price,
 		&transactionView.Amount,
+		&transactionView.Status,
 		&transactionView.CreatedAt,
 		&transactionView.UpdatedAt,
 		&transactionView.DeletedAt,
 	)
 	if err != nil {
 		return model.TransactionView{}, fmt.Errorf(
-			"TransactionPostgres - GetByItemName - p.pg.Pool.QueryRow: %w", err,
+			"TransactionPostgres - GetByItemName - conn.QueryRow: %w", err,
 		)
 	}
 	return transactionView, nil
@@ -410,67 +741,198 @@ func (p *TransactionPostgres) GetByItemName(
 
 type mod model.TransactionFilter
 
+const transactionViewQuery = `
+	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.status, t.created_at, t.updated_at, t.deleted_at
+	FROM ` + TransactionTable + ` AS t
+	INNER JOIN customer AS c ON t.customer_id = c.id
+	INNER JOIN item AS i ON t.item_id = i.id`
+
+// transactionSortColumns maps model.TransactionSortFields to their columns
+var transactionSortColumns = map[string]string{
+	"id":            "t.id",
+	"created_at":    "t.created_at",
+	"updated_at":    "t.updated_at",
+	"amount":        "t.amount",
+	"price":         "t.price",
+	"qty":           "t.qty",
+	"status":        "t.status",
+	"customer_name": "c.customer_name",
+	"item_name":     "i.item_name",
+}
+
+func scanTransactionView(row pgx.Row) (model.TransactionView, error) {
+	var transactionView model.TransactionView
+	err := row.Scan(
+		&transactionView.ID,
+		&transactionView.CustomerID,
+		&transactionView.CustomerName,
+		&transactionView.ItemID,
+		&transactionView.ItemName,
+		&transactionView.Qty,
+		&transactionView.Price,
+		&transactionView.Amount,
+		&transactionView.Status,
+		&transactionView.CreatedAt,
+		&transactionView.UpdatedAt,
+		&transactionView.DeletedAt,
+	)
+	return transactionView, err
+}
+
+// GetQuery builds the AND conditions of the filter and their arguments
 func GetQuery(t model.TransactionFilter) (string, []interface{}) {
 	var query string
 	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		query += " AND " + strings.ReplaceAll(condition, "$?", "$"+strconv.Itoa(len(args)))
+	}
 	if t.CustomerName != "" {
-		args = append(args, t.CustomerName)
-		query += " AND customer_name = $" + strconv.Itoa(len(args))
+		add("c.customer_name ILIKE $?", "%"+escapeLike(t.CustomerName)+"%")
 	}
 	if t.ItemName != "" {
-		args = append(args, t.ItemName)
-		query += " AND item_name = $" + strconv.Itoa(len(args))
+		add("i.item_name ILIKE $?", "%"+escapeLike(t.ItemName)+"%")
 	}
 	if t.ID != 0 {
-		args = append(args, t.ID)
-		query += " AND id = $" + strconv.Itoa(len(args))
+		add("t.id = $?", t.ID)
+	}
+	if len(t.CustomerIDs) > 0 {
+		add("t.customer_id = ANY($?)", t.CustomerIDs)
+	}
+	if len(t.ItemIDs) > 0 {
+		add("t.item_id = ANY($?)", t.ItemIDs)
+	}
+	if len(t.Statuses) > 0 {
+		add("t.status = ANY($?)", t.Statuses)
+	}
+	if t.CreatedFrom != nil {
+		add("t.created_at >= $?", *t.CreatedFrom)
+	}
+	if t.CreatedTo != nil {
+		add("t.created_at <= $?", *t.CreatedTo)
+	}
+	if t.AmountMin != nil {
+		add("t.amount >= $?", *t.AmountMin)
+	}
+	if t.AmountMax != nil {
+		add("t.amount <= $?", *t.AmountMax)
+	}
+	if t.QtyMin != nil {
+		add("t.qty >= $?", *t.QtyMin)
+	}
+	if t.QtyMax != nil {
+		add("t.qty <= $?", *t.QtyMax)
 	}
 	return query, args
 }
 
-func (p *TransactionPostgres) GetAllTransactionViewsByFilters(ctx context.Context, filter *model.TransactionFilter) ([]model.TransactionView, error) {
-	query := `
-	SELECT t.id, t.customer_id, c.customer_name, t.item_id, i.item_name, t.qty, t.price, t.amount, t.created_at, t.updated_at, t.deleted_at
-	FROM ` + TransactionTable + ` AS t
+// getOrderBy builds the ORDER BY clause of the sort, the id is always last so pages are stable
+func getOrderBy(sort []model.TransactionSort) string {
+	var order []string
+	for _, s := range sort {
+		column, ok := transactionSortColumns[s.Field]
+		if !ok {
+			continue
+		}
+		if s.Desc {
+			column += " DESC"
+		}
+		order = append(order, column)
+	}
+	return " ORDER BY " + strings.Join(append(order, "t.id"), ", ")
+}
+
+func (p *TransactionPostgres) GetAllTransactionViewsByFilters(
+	ctx context.Context,
+	filter *model.TransactionFilter,
+) (model.TransactionViewPage, error) {
+	page := model.TransactionViewPage{Limit: filter.Limit, Offset: filter.Offset}
+	where, args := GetQuery(*filter)
+	args = append(args, tenant.ID(ctx))
+	where = " WHERE t.deleted_at IS NULL AND t.tenant_id = $" + strconv.Itoa(len(args)) + where
+
+	err := conn(ctx, p.pg).QueryRow(
+		ctx, `
+	SELECT COUNT(*)
+	FROM `+TransactionTable+` AS t
 	INNER JOIN customer AS c ON t.customer_id = c.id
-	INNER JOIN item AS i ON t.item_id = i.id
-	WHERE t.deleted_at IS NULL `
-	queryAdd, args := GetQuery(*filter)
-	var err error
-	var rows pgx.Rows
-	if len(args) > 0 {
-		query += queryAdd
-		rows, err = p.pg.Pool.Query(ctx, query, args...)
-	} else {
-		rows, err = p.pg.Pool.Query(ctx, query, args...)
+	INNER JOIN item AS i ON t.item_id = i.id`+where, args...,
+	).Scan(&page.Total)
+	if err != nil {
+		return model.TransactionViewPage{}, fmt.Errorf(
+			"TransactionPostgres - GetAllTransactionViewsByFilters - conn.QueryRow: %w",
+			err,
+		)
 	}
+
+	rows, err := conn(ctx, p.pg).Query(
+		ctx,
+		transactionViewQuery+where+getOrderBy(filter.Sort)+getLimitAndOffset(filter.Limit, filter.Offset),
+		args...,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("TransactionPostgres - GetAllTransactionViewsByFilters - p.pg.Pool.Query: %w", err)
+		return model.TransactionViewPage{}, fmt.Errorf(
+			"TransactionPostgres - GetAllTransactionViewsByFilters - conn.Query: %w",
+			err,
+		)
 	}
-	var transactionViews []model.TransactionView
+	defer rows.Close()
+
+	page.Items = []model.TransactionView{}
 	for rows.Next() {
-		var transactionView model.TransactionView
-		err := rows.Scan(
-			&transactionView.ID,
-			&transactionView.CustomerID,
-			&transactionView.CustomerName,
-			&transactionView.ItemID,
-			&transactionView.ItemName,
-			&transactionView.Qty,
-			&transactionView.Price,
-			&transactionView.Amount,
-			&transactionView.CreatedAt,
-			&transactionView.UpdatedAt,
-			&transactionView.DeletedAt,
-		)
+		transactionView, err := scanTransactionView(rows)
 		if err != nil {
-			return nil, fmt.Errorf(
+			return model.TransactionViewPage{}, fmt.Errorf(
 				"TransactionPostgres - GetAllTransactionViewsByFilters - rows.Scan: %w",
 				err,
 			)
 		}
-		transactionViews = append(transactionViews, transactionView)
+		page.Items = append(page.Items, transactionView)
+	}
+
+	if err := rows.Err(); err != nil {
+		return model.TransactionViewPage{}, fmt.Errorf(
+			"TransactionPostgres - GetAllTransactionViewsByFilters - rows.Err: %w",
+			err,
+		)
 	}
-	return transactionViews, nil
 
+	return page, nil
+}
+
+// StreamTransactionViewsByFilters calls fn for every view matching the filter in the sort order,
+// rows are read one at a time so the result set is never held in memory
+func (p *TransactionPostgres) StreamTransactionViewsByFilters(
+	ctx context.Context,
+	filter *model.TransactionFilter,
+	fn func(model.TransactionView) error,
+) error {
+	where, args := GetQuery(*filter)
+	args = append(args, tenant.ID(ctx))
+	rows, err := conn(ctx, p.pg).Query(
+		ctx,
+		transactionViewQuery+" WHERE t.deleted_at IS NULL AND t.tenant_id = $"+strconv.Itoa(len(args))+where+getOrderBy(filter.Sort)+
+			getLimitAndOffset(filter.Limit, filter.Offset),
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("TransactionPostgres - StreamTransactionViewsByFilters - conn.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transactionView, err := scanTransactionView(rows)
+		if err != nil {
+			return fmt.Errorf("TransactionPostgres - StreamTransactionViewsByFilters - rows.Scan: %w", err)
+		}
+		if err := fn(transactionView); err != nil {
+			return fmt.Errorf("TransactionPostgres - StreamTransactionViewsByFilters - fn: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("TransactionPostgres - StreamTransactionViewsByFilters - rows.Err: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,152 @@
+package postgresSQL
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+func TestCreateTransactionConcurrent(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	const (
+		opening   = model.Money(1000)
+		price     = model.Money(300)
+		purchases = 20
+	)
+	transactions := NewTransactionPostgres(pg)
+	customerID := testCustomer(t, ctx, pg, opening)
+	itemID := testItem(t, ctx, pg, price)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		charged int
+	)
+	for i := 0; i < purchases; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := transactions.Create(ctx, model.Transaction{
+				CustomerID: customerID,
+				ItemID:     itemID,
+				Qty:        1,
+				Price:      price,
+				Amount:     price,
+				Status:     model.TransactionStatusPaid,
+				CreatedBy:  "test",
+			})
+			if err != nil {
+				if !errors.Is(err, model.ErrInsufficientBalance) {
+					t.Errorf("Create: got %v, want model.ErrInsufficientBalance", err)
+				}
+				return
+			}
+			mu.Lock()
+			charged++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if want := int(opening / price); charged != want {
+		t.Errorf("%d purchases were charged, the balance covers %d", charged, want)
+	}
+	customer, err := NewCustomerPostgres(pg).GetByID(ctx, customerID)
+	if err != nil {
+		t.Fatalf("CustomerPostgres.GetByID: %v", err)
+	}
+	if customer.Balance < 0 {
+		t.Errorf("balance went negative: %v", customer.Balance)
+	}
+	if want := opening - price.Mul(charged); customer.Balance != want {
+		t.Errorf("balance is %v, want %v", customer.Balance, want)
+	}
+}
+
+func TestUpdateTransactionConcurrent(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	const (
+		opening = model.Money(1000)
+		price   = model.Money(100)
+		count   = 5
+		raised  = 4
+	)
+	transactions := NewTransactionPostgres(pg)
+	customerID := testCustomer(t, ctx, pg, opening)
+	itemID := testItem(t, ctx, pg, price)
+
+	// five paid transactions of 1.00 leave 5.00, raising one to 4.00 takes 3.00 more so only one raise fits
+	ids := make([]int, count)
+	for i := range ids {
+		id, err := transactions.Create(ctx, model.Transaction{
+			CustomerID: customerID,
+			ItemID:     itemID,
+			Qty:        1,
+			Price:      price,
+			Amount:     price,
+			Status:     model.TransactionStatusPaid,
+			CreatedBy:  "test",
+		})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids[i] = id
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		raises int
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			_, err := transactions.Update(ctx, model.Transaction{
+				ID:         id,
+				CustomerID: customerID,
+				ItemID:     itemID,
+				Qty:        raised,
+				Price:      price,
+				Amount:     price.Mul(raised),
+				UpdatedBy:  "test",
+			})
+			if err != nil {
+				if !errors.Is(err, model.ErrInsufficientBalance) {
+					t.Errorf("Update: got %v, want model.ErrInsufficientBalance", err)
+				}
+				return
+			}
+			mu.Lock()
+			raises++
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	if raises != 1 {
+		t.Errorf("%d raises succeeded, the balance covers 1", raises)
+	}
+	var charges model.Money
+	for _, id := range ids {
+		transaction, err := transactions.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		charges += transaction.Amount
+	}
+	customer, err := NewCustomerPostgres(pg).GetByID(ctx, customerID)
+	if err != nil {
+		t.Fatalf("CustomerPostgres.GetByID: %v", err)
+	}
+	if customer.Balance < 0 {
+		t.Errorf("balance went negative: %v", customer.Balance)
+	}
+	if want := opening - charges; customer.Balance != want {
+		t.Errorf("balance is %v, want %v", customer.Balance, want)
+	}
+}
@@ -0,0 +1,55 @@
+package postgresSQL
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+)
+
+// db is what the pool and a transaction have in common
+type db interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction InTx started for ctx and the pool outside of one.
+// Transactions begun on it inside InTx are savepoints of the outer transaction.
+func conn(ctx context.Context, pg *postgres.Postgres) db {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pg.Pool
+}
+
+type TxPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewTxPostgres(pg *postgres.Postgres) *TxPostgres {
+	return &TxPostgres{pg: pg}
+}
+
+// InTx runs fn in one database transaction, the repositories called with the ctx of fn take part in it.
+// The transaction is committed when fn returns nil and rolled back otherwise.
+func (p *TxPostgres) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := conn(ctx, p.pg).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("TxPostgres - InTx - conn.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("TxPostgres - InTx - tx.Commit: %w", err)
+	}
+	return nil
+}
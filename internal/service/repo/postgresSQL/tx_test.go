@@ -0,0 +1,50 @@
+package postgresSQL
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+)
+
+func TestInTxRollsBack(t *testing.T) {
+	pg := testPostgres(t)
+	ctx := testTenant(t, pg)
+	items := NewItemPostgres(pg)
+	audit := NewAuditPostgres(pg)
+	failed := errors.New("audit entry can't be written")
+
+	var id int
+	err := NewTxPostgres(pg).InTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = items.Create(ctx, model.Item{ItemName: "item", Cost: 100, Price: 200, CreatedBy: "test"})
+		if err != nil {
+			return err
+		}
+		err = audit.Create(ctx, model.AuditEntry{
+			Actor:    "test",
+			Action:   model.AuditActionCreate,
+			Entity:   model.AuditEntityItem,
+			EntityID: id,
+		})
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("InTx: got %v, want %v", err, failed)
+	}
+	if _, err := items.GetByID(ctx, id); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetByID: got %v, want pgx.ErrNoRows", err)
+	}
+	page, err := audit.GetByFilter(ctx, model.AuditFilter{Entity: model.AuditEntityItem, EntityID: id, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetByFilter: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("%d audit entries were kept after the rollback", page.Total)
+	}
+}
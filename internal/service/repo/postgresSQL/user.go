@@ -0,0 +1,147 @@
+package postgresSQL
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/robertt3kuk/xiaoma-test-task/init/postgres"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tenant"
+)
+
+type UserPostgres struct {
+	pg *postgres.Postgres
+}
+
+func NewUserPostgres(pg *postgres.Postgres) *UserPostgres {
+	return &UserPostgres{pg: pg}
+}
+
+const UserTable = "app_user"
+
+const userColumns = `id, tenant_id, username, role, created_at, updated_at, disabled_at`
+
+// pgUniqueViolation is the postgres error code of a unique constraint violation
+const pgUniqueViolation = "23505"
+
+func scanUser(row pgx.Row, extra ...any) (model.User, error) {
+	var user model.User
+	err := row.Scan(append([]any{
+		&user.ID,
+		&user.TenantID,
+		&user.Username,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DisabledAt,
+	}, extra...)...)
+	return user, err
+}
+
+func (p *UserPostgres) Create(ctx context.Context, user model.User, passwordHash string) (model.User, error) {
+	user, err := scanUser(p.pg.Pool.QueryRow(
+		ctx, `
+	INSERT INTO `+UserTable+`
+	(tenant_id, username, password_hash, role)
+	VALUES ($1, $2, $3, $4)
+	RETURNING `+userColumns,
+		tenant.ID(ctx), user.Username, passwordHash, user.Role,
+	))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return model.User{}, model.ErrUsernameTaken
+		}
+		return model.User{}, fmt.Errorf("UserPostgres - Create - p.pg.Pool.QueryRow: %w", err)
+	}
+	return user, nil
+}
+
+// GetByID returns the user of any tenant, it authenticates tokens before the tenant is known
+func (p *UserPostgres) GetByID(ctx context.Context, id int) (model.User, error) {
+	user, err := scanUser(p.pg.Pool.QueryRow(
+		ctx, `
+	SELECT `+userColumns+`
+	FROM `+UserTable+`
+	WHERE id = $1
+`, id,
+	))
+	if err != nil {
+		return model.User{}, fmt.Errorf("UserPostgres - GetByID - p.pg.Pool.QueryRow: %w", err)
+	}
+	return user, nil
+}
+
+// GetCredentials returns the user of any tenant and the password hash, pgx.ErrNoRows means the username is unknown
+func (p *UserPostgres) GetCredentials(ctx context.Context, username string) (model.User, string, error) {
+	var passwordHash string
+	user, err := scanUser(p.pg.Pool.QueryRow(
+		ctx, `
+	SELECT `+userColumns+`, password_hash
+	FROM `+UserTable+`
+	WHERE username = $1
+`, username,
+	), &passwordHash)
+	if err != nil {
+		return model.User{}, "", fmt.Errorf("UserPostgres - GetCredentials - p.pg.Pool.QueryRow: %w", err)
+	}
+	return user, passwordHash, nil
+}
+
+func (p *UserPostgres) GetAll(ctx context.Context) ([]model.User, error) {
+	rows, err := p.pg.Pool.Query(
+		ctx, `
+	SELECT `+userColumns+`
+	FROM `+UserTable+`
+	WHERE tenant_id = $1
+	ORDER BY id
+`, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("UserPostgres - GetAll - p.pg.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("UserPostgres - GetAll - rows.Scan: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("UserPostgres - GetAll - rows.Err: %w", err)
+	}
+	return users, nil
+}
+
+// Update changes the role, the password hash and the disabled flag when they are not nil,
+// pgx.ErrNoRows means the user doesn't exist
+func (p *UserPostgres) Update(
+	ctx context.Context,
+	update model.UserUpdate,
+	passwordHash *string,
+) (model.User, error) {
+	user, err := scanUser(p.pg.Pool.QueryRow(
+		ctx, `
+	UPDATE `+UserTable+`
+	SET role = COALESCE($2, role),
+		password_hash = COALESCE($3, password_hash),
+		disabled_at = CASE
+			WHEN $4::BOOLEAN IS NULL THEN disabled_at
+			WHEN $4 THEN COALESCE(disabled_at, now())
+			ELSE NULL
+		END,
+		updated_at = now()
+	WHERE id = $1 AND tenant_id = $5
+	RETURNING `+userColumns,
+		update.ID, update.Role, passwordHash, update.Disabled, tenant.ID(ctx),
+	))
+	if err != nil {
+		return model.User{}, fmt.Errorf("UserPostgres - Update - p.pg.Pool.QueryRow: %w", err)
+	}
+	return user, nil
+}
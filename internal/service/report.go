@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type ReportService struct {
+	t ReportRepository
+}
+
+func NewReportService(t ReportRepository) *ReportService {
+	return &ReportService{t: t}
+}
+
+func (s *ReportService) Sales(ctx context.Context, filter model.ReportFilter) ([]model.SalesReportRow, Status) {
+	ctx, span := tracing.Start(ctx, "ReportService.Sales")
+	defer span.End()
+	var status Status
+	if filter.GroupBy == "" {
+		filter.GroupBy = model.ReportGroupDay
+	}
+	if err := validateReportFilter(filter); err != nil {
+		return nil, status.withError(
+			"ReportService - Sales - validateReportFilter:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	report, err := s.t.Sales(ctx, filter)
+	if err != nil {
+		return nil, status.withError(
+			"ReportService - Sales - s.t.Sales:%w",
+			err,
+			"couldn't build sales report",
+			http.StatusInternalServerError,
+		)
+	}
+	return report, status.success("sales report built", http.StatusOK)
+}
+
+func (s *ReportService) Items(ctx context.Context, filter model.ReportFilter) ([]model.ItemReportRow, Status) {
+	ctx, span := tracing.Start(ctx, "ReportService.Items")
+	defer span.End()
+	var status Status
+	if err := validateReportFilter(filter); err != nil {
+		return nil, status.withError(
+			"ReportService - Items - validateReportFilter:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	report, err := s.t.Items(ctx, filter)
+	if err != nil {
+		return nil, status.withError(
+			"ReportService - Items - s.t.Items:%w",
+			err,
+			"couldn't build items report",
+			http.StatusInternalServerError,
+		)
+	}
+	return report, status.success("items report built", http.StatusOK)
+}
+
+func (s *ReportService) Customers(
+	ctx context.Context,
+	filter model.ReportFilter,
+) ([]model.CustomerReportRow, Status) {
+	ctx, span := tracing.Start(ctx, "ReportService.Customers")
+	defer span.End()
+	var status Status
+	if err := validateReportFilter(filter); err != nil {
+		return nil, status.withError(
+			"ReportService - Customers - validateReportFilter:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	report, err := s.t.Customers(ctx, filter)
+	if err != nil {
+		return nil, status.withError(
+			"ReportService - Customers - s.t.Customers:%w",
+			err,
+			"couldn't build customers report",
+			http.StatusInternalServerError,
+		)
+	}
+	return report, status.success("customers report built", http.StatusOK)
+}
+
+func validateReportFilter(filter model.ReportFilter) error {
+	switch filter.GroupBy {
+	case "", model.ReportGroupDay, model.ReportGroupWeek, model.ReportGroupMonth:
+	default:
+		return errors.New("group_by must be day, week or month")
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return errors.New("from is after to")
+	}
+	return nil
+}
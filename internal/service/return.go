@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type ReturnService struct {
+	r     ReturnRepository
+	t     TransactionRepository
+	audit *AuditService
+}
+
+func NewReturnService(r ReturnRepository, t TransactionRepository, audit *AuditService) *ReturnService {
+	return &ReturnService{r: r, t: t, audit: audit}
+}
+
+func (s *ReturnService) Create(
+	ctx context.Context,
+	ret model.TransactionReturn,
+) (model.TransactionReturn, Status) {
+	ctx, span := tracing.Start(ctx, "ReturnService.Create")
+	defer span.End()
+	ret.Operator = ActorFromContext(ctx).Name
+	var status Status
+	status = s.transactionExists(ctx, "ReturnService - Create", ret.TransactionID)
+	if !status.Ok() {
+		return ret, status
+	}
+	// a return is audited as an update of its transaction, the returned units and amount change.
+	// The transaction stays locked from the before snapshot until the entry is written.
+	err := s.audit.change(ctx, func(ctx context.Context) error {
+		before, err := s.t.GetForUpdate(ctx, ret.TransactionID)
+		if err != nil {
+			return err
+		}
+		created, err := s.r.Create(ctx, ret)
+		if err != nil {
+			return err
+		}
+		ret = created
+		after, err := s.t.GetByID(ctx, ret.TransactionID)
+		if err != nil {
+			return err
+		}
+		return s.audit.record(ctx, model.AuditEntityTransaction, model.AuditActionUpdate, ret.TransactionID, before, after)
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrReturnExceedsSold) {
+			return ret, status.withError(
+				"ReturnService - Create - s.r.Create:%w",
+				err,
+				"return qty is more than the units left on the transaction",
+				http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, model.ErrAlreadyVoided) {
+			return ret, status.withError(
+				"ReturnService - Create - s.r.Create:%w",
+				err,
+				"transaction is voided",
+				http.StatusConflict,
+			)
+		}
+		if errors.Is(err, model.ErrIllegalTransition) {
+			return ret, status.withError(
+				"ReturnService - Create - s.r.Create:%w",
+				err,
+				"only paid or fulfilled transactions can be returned",
+				http.StatusConflict,
+			)
+		}
+		return ret, status.withError(
+			"ReturnService - Create - s.r.Create:%w",
+			err,
+			"couldn't create return",
+			http.StatusInternalServerError,
+		)
+	}
+	metrics.Refunded(metrics.RefundReturn, ret.Amount)
+	return ret, status.success("return created", http.StatusCreated)
+}
+
+func (s *ReturnService) GetByTransactionID(
+	ctx context.Context,
+	transactionID int,
+) ([]model.TransactionReturn, Status) {
+	ctx, span := tracing.Start(ctx, "ReturnService.GetByTransactionID")
+	defer span.End()
+	var status Status
+	status = s.transactionExists(ctx, "ReturnService - GetByTransactionID", transactionID)
+	if !status.Ok() {
+		return nil, status
+	}
+	returns, err := s.r.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, status.withError(
+			"ReturnService - GetByTransactionID - s.r.GetByTransactionID:%w",
+			err,
+			"couldn't get returns",
+			http.StatusInternalServerError,
+		)
+	}
+	return returns, status.success("returns retrieved", http.StatusOK)
+}
+
+func (s *ReturnService) transactionExists(ctx context.Context, caller string, transactionID int) Status {
+	var status Status
+	exist, err := s.t.IDExists(ctx, transactionID)
+	if err != nil {
+		return status.withError(
+			caller+" - s.t.IDExists:%w",
+			err,
+			"error with transaction id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return status.withError(
+			caller+" - s.t.IDExists:%w",
+			err,
+			"transaction does not exist",
+			http.StatusNotFound,
+		)
+	}
+	return status.success("transaction exists", http.StatusOK)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+type StockService struct {
+	t StockRepository
+	i ItemRepository
+}
+
+func NewStockService(t StockRepository, i ItemRepository) *StockService {
+	return &StockService{t: t, i: i}
+}
+
+func (s *StockService) Receive(ctx context.Context, operation model.StockOperation) (int, Status) {
+	ctx, span := tracing.Start(ctx, "StockService.Receive")
+	defer span.End()
+	operation.Operator = ActorFromContext(ctx).Name
+	var status Status
+	status = s.itemExists(ctx, "StockService - Receive", operation.ItemID)
+	if !status.Ok() {
+		return 0, status
+	}
+	stock, err := s.t.Receive(ctx, operation)
+	if err != nil {
+		return 0, status.withError(
+			"StockService - Receive - s.t.Receive:%w",
+			err,
+			"couldn't receive item stock",
+			http.StatusInternalServerError,
+		)
+	}
+	return stock, status.success("stock received", http.StatusOK)
+}
+
+func (s *StockService) Adjust(ctx context.Context, operation model.StockOperation) (int, Status) {
+	ctx, span := tracing.Start(ctx, "StockService.Adjust")
+	defer span.End()
+	operation.Operator = ActorFromContext(ctx).Name
+	var status Status
+	status = s.itemExists(ctx, "StockService - Adjust", operation.ItemID)
+	if !status.Ok() {
+		return 0, status
+	}
+	stock, err := s.t.Adjust(ctx, operation)
+	if err != nil {
+		if errors.Is(err, model.ErrInsufficientStock) {
+			return 0, status.withError(
+				"StockService - Adjust - s.t.Adjust:%w",
+				err,
+				"item stock can't go below zero",
+				http.StatusBadRequest,
+			)
+		}
+		return 0, status.withError(
+			"StockService - Adjust - s.t.Adjust:%w",
+			err,
+			"couldn't adjust item stock",
+			http.StatusInternalServerError,
+		)
+	}
+	return stock, status.success("stock adjusted", http.StatusOK)
+}
+
+func (s *StockService) GetMovements(
+	ctx context.Context,
+	itemID, limit, offset int,
+) ([]model.StockMovement, Status) {
+	ctx, span := tracing.Start(ctx, "StockService.GetMovements")
+	defer span.End()
+	var status Status
+	status = s.itemExists(ctx, "StockService - GetMovements", itemID)
+	if !status.Ok() {
+		return nil, status
+	}
+	movements, err := s.t.GetMovements(ctx, itemID, limit, offset)
+	if err != nil {
+		return nil, status.withError(
+			"StockService - GetMovements - s.t.GetMovements:%w",
+			err,
+			"couldn't get stock movements",
+			http.StatusInternalServerError,
+		)
+	}
+	return movements, status.success("stock movements retrieved", http.StatusOK)
+}
+
+func (s *StockService) itemExists(ctx context.Context, caller string, itemID int) Status {
+	var status Status
+	exist, err := s.i.IDExists(ctx, itemID)
+	if err != nil {
+		return status.withError(
+			caller+" - s.i.IDExists:%w",
+			err,
+			"error with item id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return status.withError(
+			caller+" - s.i.IDExists:%w",
+			err,
+			"item does not exist",
+			http.StatusNotFound,
+		)
+	}
+	return status.success("item exists", http.StatusOK)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+)
+
+// tenantSlug is lowercase letters, digits and dashes so it can be used in urls and file names
+var tenantSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,63}$`)
+
+type TenantService struct {
+	t TenantRepository
+}
+
+func NewTenantService(t TenantRepository) *TenantService {
+	return &TenantService{t: t}
+}
+
+func (s *TenantService) Create(ctx context.Context, tenant model.Tenant) (model.Tenant, Status) {
+	ctx, span := tracing.Start(ctx, "TenantService.Create")
+	defer span.End()
+	var status Status
+	tenant.Slug = strings.TrimSpace(tenant.Slug)
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	if !tenantSlug.MatchString(tenant.Slug) {
+		return tenant, status.withError(
+			"TenantService - Create - tenantSlug.MatchString:%w",
+			nil,
+			"slug must be 2 to 64 lowercase letters, digits or dashes",
+			http.StatusBadRequest,
+		)
+	}
+	if tenant.Name == "" {
+		return tenant, status.withError(
+			"TenantService - Create - tenant.Name:%w", nil, "name is required", http.StatusBadRequest,
+		)
+	}
+	tenant, err := s.t.Create(ctx, tenant)
+	if err != nil {
+		if errors.Is(err, model.ErrTenantSlugTaken) {
+			return tenant, status.withError("TenantService - Create - s.t.Create:%w", err, "slug is taken", http.StatusConflict)
+		}
+		return tenant, status.withError(
+			"TenantService - Create - s.t.Create:%w", err, "couldn't create tenant", http.StatusInternalServerError,
+		)
+	}
+	return tenant, status.success("tenant created", http.StatusCreated)
+}
+
+func (s *TenantService) GetByID(ctx context.Context, id int) (model.Tenant, Status) {
+	ctx, span := tracing.Start(ctx, "TenantService.GetByID")
+	defer span.End()
+	var status Status
+	tenant, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return tenant, status.withError(
+				"TenantService - GetByID - s.t.GetByID:%w", err, "tenant does not exist", http.StatusNotFound,
+			)
+		}
+		return tenant, status.withError(
+			"TenantService - GetByID - s.t.GetByID:%w", err, "couldn't get tenant", http.StatusInternalServerError,
+		)
+	}
+	return tenant, status.success("tenant found", http.StatusOK)
+}
+
+func (s *TenantService) GetAll(ctx context.Context) ([]model.Tenant, Status) {
+	ctx, span := tracing.Start(ctx, "TenantService.GetAll")
+	defer span.End()
+	var status Status
+	tenants, err := s.t.GetAll(ctx)
+	if err != nil {
+		return nil, status.withError(
+			"TenantService - GetAll - s.t.GetAll:%w", err, "couldn't get tenants", http.StatusInternalServerError,
+		)
+	}
+	return tenants, status.success("tenants found", http.StatusOK)
+}
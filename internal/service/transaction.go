@@ -2,26 +2,35 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/metrics"
 	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
 )
 
 type TransactionService struct {
-	t TransactionRepository
-	c CustomerRepository
-	i ItemRepository
+	t     TransactionRepository
+	c     CustomerRepository
+	i     ItemRepository
+	audit *AuditService
 }
 
 func NewTransactionService(
 	t TransactionRepository,
 	c CustomerRepository,
 	i ItemRepository,
+	audit *AuditService,
 ) *TransactionService {
 	return &TransactionService{
-		t: t,
-		c: c,
-		i: i,
+		t:     t,
+		c:     c,
+		i:     i,
+		audit: audit,
 	}
 }
 
@@ -29,6 +38,8 @@ func (s *TransactionService) Create(
 	ctx context.Context,
 	transaction model.Transaction,
 ) (int, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Create")
+	defer span.End()
 	var status Status
 	ItemIDExistss, err := s.i.IDExists(ctx, transaction.ItemID)
 	if err != nil {
@@ -64,27 +75,59 @@ func (s *TransactionService) Create(
 			http.StatusNotFound,
 		)
 	}
-
-	balance, err := s.c.GetBalance(ctx, transaction.CustomerID)
-	if err != nil {
-		return 0, status.withError(
-			"TransactionService - Create - s.c.GetBalance:%w",
-			err,
-			"error with customer balance",
-			http.StatusInternalServerError,
-		)
-	}
-	if balance < transaction.Amount {
+	if transaction.Status != model.TransactionStatusPending && transaction.Status != model.TransactionStatusPaid {
 		return 0, status.withError(
-			"TransactionService - Create - s.c.GetBalance:%w",
-			err,
-			"customer balance is not enough",
+			"TransactionService - Create - transaction.Status:%w",
+			nil,
+			"transaction can only be created pending or paid",
 			http.StatusBadRequest,
 		)
 	}
+	status = s.price(ctx, &transaction)
+	if !status.Ok() {
+		return 0, status
+	}
 
-	id, err := s.t.Create(ctx, transaction)
+	transaction.CreatedBy = ActorFromContext(ctx).Name
+	transaction.UpdatedBy = transaction.CreatedBy
+	// the balance is checked and debited under a row lock by the repository
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		id, err := s.t.Create(ctx, transaction)
+		if err != nil {
+			return err
+		}
+		transaction.ID = id
+		if created, err := s.t.GetByID(ctx, id); err == nil {
+			transaction = created
+		}
+		return s.audit.record(ctx, model.AuditEntityTransaction, model.AuditActionCreate, id, nil, transaction)
+	})
 	if err != nil {
+		if errors.Is(err, model.ErrInsufficientBalance) {
+			metrics.BalanceCheckFailed(metrics.OperationTransactionCreate)
+			return 0, status.withError(
+				"TransactionService - Create - s.t.Create:%w",
+				err,
+				"customer balance is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, status.withError(
+				"TransactionService - Create - s.t.Create:%w",
+				err,
+				"customer id does not exist",
+				http.StatusNotFound,
+			)
+		}
+		if errors.Is(err, model.ErrInsufficientStock) {
+			return 0, status.withError(
+				"TransactionService - Create - s.t.Create:%w",
+				err,
+				"item stock is not enough",
+				http.StatusBadRequest,
+			)
+		}
 		return 0, status.withError(
 			"TransactionService - Create - s.t.Create:%w",
 			err,
@@ -92,10 +135,13 @@ func (s *TransactionService) Create(
 			http.StatusInternalServerError,
 		)
 	}
-	return id, status.success("transaction succesfully created", http.StatusCreated)
+	metrics.TransactionCreated(transaction.Status, transaction.Amount)
+	return transaction.ID, status.success("transaction succesfully created", http.StatusCreated)
 }
 
 func (s *TransactionService) GetByID(ctx context.Context, id int) (model.Transaction, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetByID")
+	defer span.End()
 	var status Status
 	var transaction model.Transaction
 	exist, err := s.t.IDExists(ctx, id)
@@ -125,6 +171,8 @@ func (s *TransactionService) GetAll(
 	ctx context.Context,
 	limit, offset int,
 ) ([]model.Transaction, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetAll")
+	defer span.End()
 	var status Status
 	var transactions []model.Transaction
 	transactions, err := s.t.GetAll(ctx, limit, offset)
@@ -139,41 +187,123 @@ func (s *TransactionService) GetAll(
 	return transactions, status.success("transactions retrieved", http.StatusOK)
 }
 
+func (s *TransactionService) GetPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) (model.Page[model.Transaction], Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetPage")
+	defer span.End()
+	var status Status
+	afterID, limit, err := pageQuery(cursor, limit)
+	if err != nil {
+		return model.Page[model.Transaction]{}, status.withError(
+			"TransactionService - GetPage - pageQuery:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	transactions, err := s.t.GetAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return model.Page[model.Transaction]{}, status.withError(
+			"TransactionService - GetPage - s.t.GetAfter:%w",
+			err,
+			"couldn't get all transactions",
+			http.StatusInternalServerError,
+		)
+	}
+	page := newPage(transactions, limit, func(transaction model.Transaction) int { return transaction.ID })
+	return page, status.success("transactions retrieved", http.StatusOK)
+}
+
 func (s *TransactionService) Update(
 	ctx context.Context,
 	transaction model.Transaction,
 ) (model.Transaction, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Update")
+	defer span.End()
 	var status Status
-	exist, err := s.t.IDExists(ctx, transaction.ID)
+	before, err := s.t.GetByID(ctx, transaction.ID)
 	if err != nil {
-		if !exist {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return transaction, status.withError(
-				"TransactionService - Update -  s.t.IDExists:%w",
+				"TransactionService - Update - s.t.GetByID:%w",
 				err,
 				"transaction does not exist",
 				http.StatusNotFound,
 			)
 		}
-	}
-	balance, err := s.c.GetBalance(ctx, transaction.CustomerID)
-	if err != nil {
 		return transaction, status.withError(
-			"TransactionService - Update - s.c.GetBalance:%w",
+			"TransactionService - Update - s.t.GetByID:%w",
 			err,
-			"error with customer balance",
+			"couldn't get transaction",
 			http.StatusInternalServerError,
 		)
 	}
-	if balance < transaction.Amount {
-		return transaction, status.withError(
-			"TransactionService - Update - s.c.GetBalance:%w",
-			err,
-			"customer balance is not enough",
-			http.StatusBadRequest,
-		)
+	status = s.price(ctx, &transaction)
+	if !status.Ok() {
+		return transaction, status
 	}
-	transaction, err = s.t.Update(ctx, transaction)
+	transaction.UpdatedBy = ActorFromContext(ctx).Name
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		updated, err := s.t.Update(ctx, transaction)
+		if err != nil {
+			return err
+		}
+		transaction = updated
+		return s.audit.record(ctx, model.AuditEntityTransaction, model.AuditActionUpdate, transaction.ID, before, transaction)
+	})
 	if err != nil {
+		if errors.Is(err, model.ErrInsufficientBalance) {
+			metrics.BalanceCheckFailed(metrics.OperationTransactionUpdate)
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"customer balance is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"transaction or its customer does not exist",
+				http.StatusNotFound,
+			)
+		}
+		if errors.Is(err, model.ErrInsufficientStock) {
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"item stock is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		if errors.Is(err, model.ErrAlreadyVoided) {
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"transaction is voided",
+				http.StatusConflict,
+			)
+		}
+		if errors.Is(err, model.ErrHasReturns) {
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"transaction with returns can't be updated",
+				http.StatusConflict,
+			)
+		}
+		if errors.Is(err, model.ErrIllegalTransition) {
+			return transaction, status.withError(
+				"TransactionService - Update - s.t.Update:%w",
+				err,
+				"only pending or paid transactions can be updated",
+				http.StatusConflict,
+			)
+		}
 		return transaction, status.withError(
 			"TransactionService - Update - s.t.Update:%w",
 			err,
@@ -184,10 +314,60 @@ func (s *TransactionService) Update(
 	return transaction, status.success("transaction updated", http.StatusOK)
 }
 
-func (s *TransactionService) Delete(ctx context.Context, id int) Status {
+func (s *TransactionService) Delete(ctx context.Context, id int, reason string) Status {
+	ctx, span := tracing.Start(ctx, "TransactionService.Delete")
+	defer span.End()
 	var status Status
-	err := s.t.Delete(ctx, id)
+	exist, err := s.t.IDExists(ctx, id)
 	if err != nil {
+		return status.withError(
+			"TransactionService - Delete - s.t.IDExists:%w",
+			err,
+			"error with transaction id",
+			http.StatusInternalServerError,
+		)
+	}
+	if !exist {
+		return status.withError(
+			"TransactionService - Delete - s.t.IDExists:%w",
+			err,
+			"transaction does not exist",
+			http.StatusNotFound,
+		)
+	}
+	// voided transactions are deleted as well, so an existing row that can't be read is already voided
+	before, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return status.withError(
+				"TransactionService - Delete - s.t.GetByID:%w",
+				model.ErrAlreadyVoided,
+				"transaction is already voided",
+				http.StatusConflict,
+			)
+		}
+		return status.withError(
+			"TransactionService - Delete - s.t.GetByID:%w",
+			err,
+			"couldn't get transaction",
+			http.StatusInternalServerError,
+		)
+	}
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		if err := s.t.Delete(ctx, id, reason, ActorFromContext(ctx).Name); err != nil {
+			return err
+		}
+		return s.audit.record(ctx, model.AuditEntityTransaction, model.AuditActionDelete, id, before, nil)
+	})
+	if err != nil {
+		if errors.Is(err, model.ErrAlreadyVoided) {
+			return status.withError(
+				"TransactionService - Delete - s.t.Delete:%w",
+				err,
+				"transaction is already voided",
+				http.StatusConflict,
+			)
+		}
 		return status.withError(
 			"TransactionService - Delete - s.t.Delete:%w",
 			err,
@@ -195,13 +375,116 @@ func (s *TransactionService) Delete(ctx context.Context, id int) Status {
 			http.StatusInternalServerError,
 		)
 	}
-	return status.success("transaction deleted", http.StatusOK)
+	// only a charged transaction gives money back, what was returned already was refunded by the return
+	var refunded model.Money
+	if model.IsCharged(before.Status) {
+		refunded = before.Amount - before.ReturnedAmount
+	}
+	if refunded == 0 {
+		return status.success("transaction voided", http.StatusOK)
+	}
+	metrics.Refunded(metrics.RefundVoid, refunded)
+	return status.success("transaction voided and refunded", http.StatusOK)
+}
+
+func (s *TransactionService) Transition(
+	ctx context.Context,
+	id int,
+	to, reason string,
+) (model.Transaction, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Transition")
+	defer span.End()
+	var status Status
+	transaction, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return transaction, status.withError(
+				"TransactionService - Transition - s.t.GetByID:%w",
+				err,
+				"transaction does not exist",
+				http.StatusNotFound,
+			)
+		}
+		return transaction, status.withError(
+			"TransactionService - Transition - s.t.GetByID:%w",
+			err,
+			"couldn't get transaction",
+			http.StatusInternalServerError,
+		)
+	}
+	if !model.CanTransition(transaction.Status, to) {
+		return transaction, status.withError(
+			"TransactionService - Transition - model.CanTransition:%w",
+			model.ErrIllegalTransition,
+			"transaction can't move from "+transaction.Status+" to "+to,
+			http.StatusConflict,
+		)
+	}
+	previous := transaction
+	err = s.audit.change(ctx, func(ctx context.Context) error {
+		changed, err := s.t.Transition(ctx, id, to, reason, ActorFromContext(ctx).Name)
+		if err != nil {
+			return err
+		}
+		transaction = changed
+		return s.audit.record(ctx, model.AuditEntityTransaction, model.AuditActionUpdate, id, previous, transaction)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrIllegalTransition):
+			return transaction, status.withError(
+				"TransactionService - Transition - s.t.Transition:%w",
+				err,
+				"transaction status changed concurrently",
+				http.StatusConflict,
+			)
+		case errors.Is(err, model.ErrInsufficientBalance):
+			metrics.BalanceCheckFailed(metrics.OperationTransactionPay)
+			return transaction, status.withError(
+				"TransactionService - Transition - s.t.Transition:%w",
+				err,
+				"customer balance is not enough",
+				http.StatusBadRequest,
+			)
+		case errors.Is(err, pgx.ErrNoRows):
+			return transaction, status.withError(
+				"TransactionService - Transition - s.t.Transition:%w",
+				err,
+				"transaction or its customer does not exist",
+				http.StatusNotFound,
+			)
+		case errors.Is(err, model.ErrInsufficientStock):
+			return transaction, status.withError(
+				"TransactionService - Transition - s.t.Transition:%w",
+				err,
+				"item stock is not enough",
+				http.StatusBadRequest,
+			)
+		}
+		return transaction, status.withError(
+			"TransactionService - Transition - s.t.Transition:%w",
+			err,
+			"couldn't change transaction status",
+			http.StatusInternalServerError,
+		)
+	}
+	// fulfilling moves no money, cancelling or refunding a charged transaction gives back what was not returned yet
+	switch {
+	case to == model.TransactionStatusPaid:
+		metrics.Charged(metrics.RevenueTransaction, previous.Amount)
+	case to == model.TransactionStatusRefunded,
+		to == model.TransactionStatusCancelled && model.IsCharged(previous.Status):
+		metrics.Refunded(metrics.RefundRefund, previous.Amount-previous.ReturnedAmount)
+	}
+	return transaction, status.success("transaction "+to, http.StatusOK)
 }
 
 func (s *TransactionService) GetAllTransactionViews(
 	ctx context.Context,
 	limit, offset int,
 ) ([]model.TransactionView, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetAllTransactionViews")
+	defer span.End()
 	var status Status
 	var transactions []model.TransactionView
 	transactions, err := s.t.GetAllTransactionViews(ctx, limit, offset)
@@ -216,10 +499,42 @@ func (s *TransactionService) GetAllTransactionViews(
 	return transactions, status.success("transactions retrieved", http.StatusOK)
 }
 
+func (s *TransactionService) GetTransactionViewPage(
+	ctx context.Context,
+	cursor string,
+	limit int,
+) (model.Page[model.TransactionView], Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTransactionViewPage")
+	defer span.End()
+	var status Status
+	afterID, limit, err := pageQuery(cursor, limit)
+	if err != nil {
+		return model.Page[model.TransactionView]{}, status.withError(
+			"TransactionService - GetTransactionViewPage - pageQuery:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	transactions, err := s.t.GetTransactionViewsAfter(ctx, afterID, limit+1)
+	if err != nil {
+		return model.Page[model.TransactionView]{}, status.withError(
+			"TransactionService - GetTransactionViewPage - s.t.GetTransactionViewsAfter:%w",
+			err,
+			"couldn't get all transactions",
+			http.StatusInternalServerError,
+		)
+	}
+	page := newPage(transactions, limit, func(transaction model.TransactionView) int { return transaction.ID })
+	return page, status.success("transactions retrieved", http.StatusOK)
+}
+
 func (s *TransactionService) GetByTransactionID(
 	ctx context.Context,
 	id int,
 ) (model.TransactionView, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetByTransactionID")
+	defer span.End()
 	var status Status
 	var transaction model.TransactionView
 	exist, err := s.t.IDExists(ctx, id)
@@ -248,27 +563,118 @@ func (s *TransactionService) GetByTransactionID(
 func (s *TransactionService) GetAllTransactionViewsByFilters(
 	ctx context.Context,
 	filter *model.TransactionFilter,
-) ([]model.TransactionView, Status) {
+) (model.TransactionViewPage, Status) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetAllTransactionViewsByFilters")
+	defer span.End()
 	var status Status
-	// check all fields of transaction filter if all are empty return error
-	if filter.CustomerName == "" && filter.ItemName == "" && filter.ID == 0 {
-		return nil, status.withError(
-			"TransactionService - GetAllTransactionViewsByFilters - s.t.GetAllTransactionViewsByFilters:%w",
-			nil,
-			"filter is empty",
+	if err := validateTransactionFilter(filter); err != nil {
+		return model.TransactionViewPage{}, status.withError(
+			"TransactionService - GetAllTransactionViewsByFilters - validateTransactionFilter:%w",
+			err,
+			err.Error(),
 			http.StatusBadRequest,
 		)
 	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
 
-	var transactions []model.TransactionView
-	transactions, err := s.t.GetAllTransactionViewsByFilters(ctx, filter)
+	page, err := s.t.GetAllTransactionViewsByFilters(ctx, filter)
 	if err != nil {
-		return transactions, status.withError(
+		return page, status.withError(
 			"TransactionService - GetAllTransactionViewsByFilters - s.t.GetAllTransactionViewsByFilters:%w",
 			err,
 			"couldn't get all transactions",
 			http.StatusInternalServerError,
 		)
 	}
-	return transactions, status.success("transactions retrieved", http.StatusOK)
+	return page, status.success("transactions retrieved", http.StatusOK)
+}
+
+// TransactionViewStream calls fn for every row of an export and stops at the first error
+type TransactionViewStream func(ctx context.Context, fn func(model.TransactionView) error) error
+
+// ExportTransactionViews checks the filter and returns the stream of its rows, a zero limit exports every row
+func (s *TransactionService) ExportTransactionViews(
+	filter *model.TransactionFilter,
+) (TransactionViewStream, Status) {
+	var status Status
+	if err := validateTransactionFilter(filter); err != nil {
+		return nil, status.withError(
+			"TransactionService - ExportTransactionViews - validateTransactionFilter:%w",
+			err,
+			err.Error(),
+			http.StatusBadRequest,
+		)
+	}
+	stream := func(ctx context.Context, fn func(model.TransactionView) error) error {
+		return s.t.StreamTransactionViewsByFilters(ctx, filter, fn)
+	}
+	return stream, status.success("transactions exported", http.StatusOK)
+}
+
+func validateTransactionFilter(filter *model.TransactionFilter) error {
+	if filter.Limit < 0 || filter.Limit > maxPageLimit {
+		return fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	if filter.Offset < 0 {
+		return errors.New("offset can't be negative")
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return errors.New("created_from is after created_to")
+	}
+	if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMin > *filter.AmountMax {
+		return errors.New("amount_min is greater than amount_max")
+	}
+	if filter.QtyMin != nil && filter.QtyMax != nil && *filter.QtyMin > *filter.QtyMax {
+		return errors.New("qty_min is greater than qty_max")
+	}
+	for _, status := range filter.Statuses {
+		if !model.IsTransactionStatus(status) {
+			return fmt.Errorf("unknown status %q", status)
+		}
+	}
+	for _, sort := range filter.Sort {
+		if !slices.Contains(model.TransactionSortFields, sort.Field) {
+			return fmt.Errorf("unknown sort field %q", sort.Field)
+		}
+	}
+	return nil
+}
+
+// price sets the transaction price from the item catalog and computes the amount,
+// a client supplied price is only kept for a price override made by a permitted actor
+func (s *TransactionService) price(ctx context.Context, transaction *model.Transaction) Status {
+	var status Status
+	item, err := s.i.GetByID(ctx, transaction.ItemID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return status.withError(
+				"TransactionService - price - s.i.GetByID:%w",
+				err,
+				"item id does not exist",
+				http.StatusNotFound,
+			)
+		}
+		return status.withError(
+			"TransactionService - price - s.i.GetByID:%w",
+			err,
+			"error with item price",
+			http.StatusInternalServerError,
+		)
+	}
+	if transaction.PriceOverrideReason != nil {
+		if !ActorFromContext(ctx).Can(model.PermissionPriceOverride) {
+			return status.withError(
+				"TransactionService - price - ActorFromContext:%w",
+				nil,
+				"price override is not permitted",
+				http.StatusForbidden,
+			)
+		}
+	} else {
+		transaction.Price = item.Price
+	}
+	transaction.Amount = transaction.Price.Mul(transaction.Qty)
+	return status.success("transaction priced", http.StatusOK)
 }
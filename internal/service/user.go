@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/model"
+	"github.com/robertt3kuk/xiaoma-test-task/internal/tracing"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// TokenConfig signs the login tokens, they are HS256 JWTs valid for TTL
+type TokenConfig struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+type UserService struct {
+	t      UserRepository
+	tokens TokenConfig
+}
+
+func NewUserService(t UserRepository, tokens TokenConfig) *UserService {
+	return &UserService{t: t, tokens: tokens}
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	// bcrypt ignores everything after 72 bytes
+	if len(password) > 72 {
+		return errors.New("password must be at most 72 bytes")
+	}
+	return nil
+}
+
+func (s *UserService) Create(ctx context.Context, user model.User, password string) (model.User, Status) {
+	ctx, span := tracing.Start(ctx, "UserService.Create")
+	defer span.End()
+	var status Status
+	user.Username = strings.TrimSpace(user.Username)
+	if len(user.Username) < 3 {
+		return user, status.withError(
+			"UserService - Create - user.Username:%w", nil, "username must be at least 3 characters", http.StatusBadRequest,
+		)
+	}
+	if !model.IsRole(user.Role) {
+		return user, status.withError(
+			"UserService - Create - model.IsRole:%w",
+			nil,
+			"role must be auditor, cashier, manager or admin",
+			http.StatusBadRequest,
+		)
+	}
+	if err := validatePassword(password); err != nil {
+		return user, status.withError("UserService - Create - validatePassword:%w", err, err.Error(), http.StatusBadRequest)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return user, status.withError(
+			"UserService - Create - bcrypt.GenerateFromPassword:%w", err, "couldn't hash password", http.StatusInternalServerError,
+		)
+	}
+	user, err = s.t.Create(ctx, user, string(hash))
+	if err != nil {
+		if errors.Is(err, model.ErrUsernameTaken) {
+			return user, status.withError("UserService - Create - s.t.Create:%w", err, "username is taken", http.StatusConflict)
+		}
+		return user, status.withError(
+			"UserService - Create - s.t.Create:%w", err, "couldn't create user", http.StatusInternalServerError,
+		)
+	}
+	return user, status.success("user created", http.StatusCreated)
+}
+
+// Login checks the password and issues a token, unknown, disabled and wrong-password logins
+// get the same answer
+func (s *UserService) Login(ctx context.Context, username, password string) (model.Token, Status) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+	var status Status
+	user, hash, err := s.t.GetCredentials(ctx, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return model.Token{}, status.withError(
+			"UserService - Login - s.t.GetCredentials:%w", err, "couldn't log in", http.StatusInternalServerError,
+		)
+	}
+	if err != nil || user.DisabledAt != nil ||
+		bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return model.Token{}, status.withError(
+			"UserService - Login - bcrypt.CompareHashAndPassword:%w",
+			err,
+			"invalid username or password",
+			http.StatusUnauthorized,
+		)
+	}
+
+	now := time.Now()
+	token := model.Token{ExpiresAt: now.Add(s.tokens.TTL)}
+	token.Token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(user.ID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+	}).SignedString(s.tokens.Secret)
+	if err != nil {
+		return model.Token{}, status.withError(
+			"UserService - Login - token.SignedString:%w", err, "couldn't sign token", http.StatusInternalServerError,
+		)
+	}
+	return token, status.success("logged in", http.StatusOK)
+}
+
+// Authenticate returns the actor of a token, the user is read again so that a role change
+// or a disabled account takes effect before the token expires
+func (s *UserService) Authenticate(ctx context.Context, token string) (model.Actor, Status) {
+	ctx, span := tracing.Start(ctx, "UserService.Authenticate")
+	defer span.End()
+	var status Status
+	claims := jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(
+		token,
+		&claims,
+		func(*jwt.Token) (any, error) { return s.tokens.Secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return model.Actor{}, status.withError(
+			"UserService - Authenticate - jwt.ParseWithClaims:%w", err, "token is invalid", http.StatusUnauthorized,
+		)
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return model.Actor{}, status.withError(
+			"UserService - Authenticate - strconv.Atoi:%w", err, "token is invalid", http.StatusUnauthorized,
+		)
+	}
+	user, err := s.t.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Actor{}, status.withError(
+				"UserService - Authenticate - s.t.GetByID:%w", err, "token is invalid", http.StatusUnauthorized,
+			)
+		}
+		return model.Actor{}, status.withError(
+			"UserService - Authenticate - s.t.GetByID:%w", err, "couldn't check token", http.StatusInternalServerError,
+		)
+	}
+	if user.DisabledAt != nil {
+		return model.Actor{}, status.withError(
+			"UserService - Authenticate - user.DisabledAt:%w", nil, "user is disabled", http.StatusUnauthorized,
+		)
+	}
+	return user.Actor(), status.success("token is valid", http.StatusOK)
+}
+
+func (s *UserService) GetAll(ctx context.Context) ([]model.User, Status) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAll")
+	defer span.End()
+	var status Status
+	users, err := s.t.GetAll(ctx)
+	if err != nil {
+		return nil, status.withError(
+			"UserService - GetAll - s.t.GetAll:%w", err, "couldn't get users", http.StatusInternalServerError,
+		)
+	}
+	return users, status.success("users found", http.StatusOK)
+}
+
+func (s *UserService) Update(ctx context.Context, update model.UserUpdate) (model.User, Status) {
+	ctx, span := tracing.Start(ctx, "UserService.Update")
+	defer span.End()
+	var status Status
+	if update.Role != nil && !model.IsRole(*update.Role) {
+		return model.User{}, status.withError(
+			"UserService - Update - model.IsRole:%w",
+			nil,
+			"role must be auditor, cashier, manager or admin",
+			http.StatusBadRequest,
+		)
+	}
+	var passwordHash *string
+	if update.Password != nil {
+		if err := validatePassword(*update.Password); err != nil {
+			return model.User{}, status.withError(
+				"UserService - Update - validatePassword:%w", err, err.Error(), http.StatusBadRequest,
+			)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(*update.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return model.User{}, status.withError(
+				"UserService - Update - bcrypt.GenerateFromPassword:%w", err, "couldn't hash password", http.StatusInternalServerError,
+			)
+		}
+		hashString := string(hash)
+		passwordHash = &hashString
+	}
+	user, err := s.t.Update(ctx, update, passwordHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, status.withError("UserService - Update - s.t.Update:%w", err, "user does not exist", http.StatusNotFound)
+		}
+		return user, status.withError(
+			"UserService - Update - s.t.Update:%w", err, "couldn't update user", http.StatusInternalServerError,
+		)
+	}
+	return user, status.success("user updated", http.StatusOK)
+}
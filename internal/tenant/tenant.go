@@ -0,0 +1,21 @@
+// Package tenant scopes a request to one shop. The repositories read the tenant from the context
+// of every query, so a context without a tenant sees no rows and can't write any.
+package tenant
+
+import "context"
+
+// Default is the tenant that existed before shops were separated, it owns all the older rows
+const Default = 1
+
+type key struct{}
+
+// WithID returns a copy of ctx scoped to the tenant
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// ID returns the tenant of ctx, 0 when ctx is not scoped
+func ID(ctx context.Context) int {
+	id, _ := ctx.Value(key{}).(int)
+	return id
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier adapts the request headers to propagation.TextMapCarrier
+type headerCarrier struct {
+	c fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// NewHTTPMiddleware starts a server span for every request, continuing the trace of the
+// traceparent header when there is one. The span context is put into c.UserContext,
+// so handlers must pass c.UserContext() to the services.
+func NewHTTPMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := otel.Tracer(instrumentationName).Start(
+			ctx,
+			c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		code := c.Response().StatusCode()
+		// the error handler writes the status after the middleware returns
+		if err != nil {
+			code = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				code = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+		// the route is only known once the router has matched it
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(code))
+		if code >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
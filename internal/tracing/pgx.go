@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer is a pgx.QueryTracer that starts a client span for every query,
+// the span is named after the SQL command and carries the query text
+type QueryTracer struct{}
+
+func NewQueryTracer() *QueryTracer {
+	return &QueryTracer{}
+}
+
+func (q *QueryTracer) TraceQueryStart(
+	ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceQueryStartData,
+) context.Context {
+	operation := sqlOperation(data.SQL)
+	ctx, _ = otel.Tracer(instrumentationName).Start(
+		ctx,
+		"postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (q *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation returns the first keyword of query, like SELECT or INSERT
+func sqlOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
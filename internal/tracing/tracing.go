@@ -0,0 +1,17 @@
+// Package tracing creates the OpenTelemetry spans of the handlers, services and queries,
+// the provider and the propagator are installed by init/tracer.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/robertt3kuk/xiaoma-test-task"
+
+// Start starts an internal span, name is Type.Method like TransactionService.Create
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name)
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS balance_entry;
+DROP FUNCTION IF EXISTS balance_entry_immutable();
@@ -0,0 +1,31 @@
+-- Balance ledger migration
+CREATE TABLE balance_entry (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL,
+    transaction_id INTEGER,
+    kind VARCHAR(32) NOT NULL,
+    amount FLOAT8 NOT NULL,
+    balance_after FLOAT8 NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    FOREIGN KEY (customer_id) REFERENCES customer(id),
+    FOREIGN KEY (transaction_id) REFERENCES transaction(id)
+);
+
+CREATE INDEX balance_entry_customer_id_idx ON balance_entry (customer_id, id);
+
+-- ledger rows are immutable
+CREATE FUNCTION balance_entry_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'balance_entry rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER balance_entry_immutable
+    BEFORE UPDATE OR DELETE ON balance_entry
+    FOR EACH ROW EXECUTE FUNCTION balance_entry_immutable();
+
+-- opening entry for every existing customer so the ledger reconciles with the stored balance
+INSERT INTO balance_entry (customer_id, kind, amount, balance_after, reason, created_at)
+SELECT id, 'credit', balance, balance, 'opening balance', created_at
+FROM customer;
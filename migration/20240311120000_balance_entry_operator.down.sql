@@ -0,0 +1 @@
+ALTER TABLE balance_entry DROP COLUMN IF EXISTS operator;
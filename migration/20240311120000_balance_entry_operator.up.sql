@@ -0,0 +1 @@
+ALTER TABLE balance_entry ADD COLUMN operator VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE transaction DROP COLUMN IF EXISTS void_reason;
+ALTER TABLE transaction DROP COLUMN IF EXISTS voided_at;
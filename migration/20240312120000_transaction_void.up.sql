@@ -0,0 +1,5 @@
+ALTER TABLE transaction ADD COLUMN voided_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transaction ADD COLUMN void_reason VARCHAR(255);
+
+-- rows soft-deleted before voiding existed were never refunded, keep them from being refunded twice
+UPDATE transaction SET voided_at = deleted_at, void_reason = 'deleted' WHERE deleted_at IS NOT NULL;
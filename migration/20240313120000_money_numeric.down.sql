@@ -0,0 +1,10 @@
+ALTER TABLE balance_entry ALTER COLUMN balance_after TYPE FLOAT8;
+ALTER TABLE balance_entry ALTER COLUMN amount TYPE FLOAT8;
+
+ALTER TABLE transaction ALTER COLUMN amount TYPE FLOAT8;
+ALTER TABLE transaction ALTER COLUMN price TYPE FLOAT8;
+
+ALTER TABLE item ALTER COLUMN price TYPE FLOAT8;
+ALTER TABLE item ALTER COLUMN cost TYPE FLOAT8;
+
+ALTER TABLE customer ALTER COLUMN balance TYPE FLOAT8;
@@ -0,0 +1,22 @@
+-- money columns are stored as exact decimals rounded to cents
+ALTER TABLE customer ALTER COLUMN balance TYPE NUMERIC(19, 2) USING round(balance::numeric, 2);
+
+ALTER TABLE item ALTER COLUMN cost TYPE NUMERIC(19, 2) USING round(cost::numeric, 2);
+ALTER TABLE item ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+
+ALTER TABLE transaction ALTER COLUMN price TYPE NUMERIC(19, 2) USING round(price::numeric, 2);
+ALTER TABLE transaction ALTER COLUMN amount TYPE NUMERIC(19, 2) USING round(amount::numeric, 2);
+
+ALTER TABLE balance_entry ALTER COLUMN amount TYPE NUMERIC(19, 2) USING round(amount::numeric, 2);
+ALTER TABLE balance_entry ALTER COLUMN balance_after TYPE NUMERIC(19, 2) USING round(balance_after::numeric, 2);
+
+-- rounding can leave the ledger a few cents away from the stored balance, record the difference
+INSERT INTO balance_entry (customer_id, kind, amount, balance_after, reason)
+SELECT c.id, 'adjustment', c.balance - l.total, c.balance, 'rounding to cents'
+FROM customer AS c
+INNER JOIN (
+    SELECT customer_id, SUM(amount) AS total
+    FROM balance_entry
+    GROUP BY customer_id
+) AS l ON l.customer_id = c.id
+WHERE c.balance <> l.total;
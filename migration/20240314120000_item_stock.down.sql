@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_movement;
+DROP FUNCTION IF EXISTS stock_movement_immutable();
+ALTER TABLE item DROP COLUMN IF EXISTS stock;
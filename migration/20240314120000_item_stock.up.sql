@@ -0,0 +1,30 @@
+-- on hand quantity, NULL means the item stock is not tracked
+ALTER TABLE item ADD COLUMN stock INTEGER CHECK (stock IS NULL OR stock >= 0);
+
+-- Stock movement migration
+CREATE TABLE stock_movement (
+    id SERIAL PRIMARY KEY,
+    item_id INTEGER NOT NULL,
+    transaction_id INTEGER,
+    kind VARCHAR(32) NOT NULL,
+    qty INTEGER NOT NULL,
+    stock_after INTEGER NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    operator VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    FOREIGN KEY (item_id) REFERENCES item(id),
+    FOREIGN KEY (transaction_id) REFERENCES transaction(id)
+);
+
+CREATE INDEX stock_movement_item_id_idx ON stock_movement (item_id, id);
+
+-- stock history rows are immutable
+CREATE FUNCTION stock_movement_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movement rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movement_immutable
+    BEFORE UPDATE OR DELETE ON stock_movement
+    FOR EACH ROW EXECUTE FUNCTION stock_movement_immutable();
@@ -0,0 +1 @@
+ALTER TABLE transaction DROP COLUMN IF EXISTS price_override_reason;
@@ -0,0 +1 @@
+ALTER TABLE transaction ADD COLUMN price_override_reason VARCHAR(255);
@@ -0,0 +1,4 @@
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS order_id;
+ALTER TABLE balance_entry DROP COLUMN IF EXISTS order_id;
+DROP TABLE IF EXISTS order_line;
+DROP TABLE IF EXISTS order_header;
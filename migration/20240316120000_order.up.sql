@@ -0,0 +1,26 @@
+-- Order migration
+CREATE TABLE order_header (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    total NUMERIC(19, 2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    FOREIGN KEY (customer_id) REFERENCES customer(id)
+);
+
+CREATE TABLE order_line (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    item_id INTEGER NOT NULL,
+    qty INTEGER NOT NULL,
+    price NUMERIC(19, 2) NOT NULL,
+    amount NUMERIC(19, 2) NOT NULL,
+    FOREIGN KEY (order_id) REFERENCES order_header(id),
+    FOREIGN KEY (item_id) REFERENCES item(id)
+);
+
+CREATE INDEX order_line_order_id_idx ON order_line (order_id);
+
+ALTER TABLE balance_entry ADD COLUMN order_id INTEGER REFERENCES order_header(id);
+ALTER TABLE stock_movement ADD COLUMN order_id INTEGER REFERENCES order_header(id);
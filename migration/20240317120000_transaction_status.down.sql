@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS transaction_status_idx;
+ALTER TABLE transaction DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE transaction DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE transaction DROP COLUMN IF EXISTS fulfilled_at;
+ALTER TABLE transaction DROP COLUMN IF EXISTS paid_at;
+ALTER TABLE transaction DROP COLUMN IF EXISTS status;
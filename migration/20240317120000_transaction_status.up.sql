@@ -0,0 +1,11 @@
+ALTER TABLE transaction ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'paid';
+ALTER TABLE transaction ADD COLUMN paid_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transaction ADD COLUMN fulfilled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transaction ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transaction ADD COLUMN refunded_at TIMESTAMP WITH TIME ZONE;
+
+-- existing transactions were charged when they were created
+UPDATE transaction SET paid_at = created_at;
+UPDATE transaction SET status = 'cancelled', cancelled_at = voided_at WHERE voided_at IS NOT NULL;
+
+CREATE INDEX transaction_status_idx ON transaction (status);
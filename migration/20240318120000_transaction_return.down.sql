@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_return;
+ALTER TABLE transaction DROP CONSTRAINT IF EXISTS transaction_returned_qty_check;
+ALTER TABLE transaction DROP COLUMN IF EXISTS returned_amount;
+ALTER TABLE transaction DROP COLUMN IF EXISTS returned_qty;
@@ -0,0 +1,19 @@
+-- units and money already given back on a transaction
+ALTER TABLE transaction ADD COLUMN returned_qty INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transaction ADD COLUMN returned_amount NUMERIC(19, 2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction ADD CONSTRAINT transaction_returned_qty_check
+    CHECK (returned_qty >= 0 AND returned_qty <= qty);
+
+-- Transaction return migration
+CREATE TABLE transaction_return (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL,
+    qty INTEGER NOT NULL CHECK (qty > 0),
+    amount NUMERIC(19, 2) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    operator VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    FOREIGN KEY (transaction_id) REFERENCES transaction(id)
+);
+
+CREATE INDEX transaction_return_transaction_id_idx ON transaction_return (transaction_id, id);
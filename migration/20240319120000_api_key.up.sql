@@ -0,0 +1,11 @@
+-- API key migration, only the sha256 of a key is stored, the key itself is shown once on creation
+CREATE TABLE api_key (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL,
+    key_hash BYTEA NOT NULL UNIQUE,
+    scope VARCHAR(32) NOT NULL CHECK (scope IN ('read_only', 'cashier', 'admin')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,7 @@
+ALTER TABLE transaction DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE transaction DROP COLUMN IF EXISTS created_by;
+ALTER TABLE customer DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE customer DROP COLUMN IF EXISTS created_by;
+ALTER TABLE item DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE item DROP COLUMN IF EXISTS created_by;
+DROP TABLE IF EXISTS app_user;
@@ -0,0 +1,18 @@
+-- User account migration, passwords are stored as bcrypt hashes
+CREATE TABLE app_user (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL CHECK (role IN ('auditor', 'cashier', 'manager', 'admin')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    disabled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- the actor, a user or an api key, that created and last changed a row
+ALTER TABLE item ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE item ADD COLUMN updated_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE customer ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE customer ADD COLUMN updated_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transaction ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transaction ADD COLUMN updated_by VARCHAR(255) NOT NULL DEFAULT '';
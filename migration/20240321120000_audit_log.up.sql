@@ -0,0 +1,25 @@
+-- Audit log migration, rows are only ever inserted: updates and deletes are rejected by a trigger
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(32) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    entity VARCHAR(32) NOT NULL CHECK (entity IN ('item', 'customer', 'transaction')),
+    entity_id INT NOT NULL,
+    before JSONB,
+    after JSONB,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,17 @@
+-- Idempotency key migration, a key is scoped to the actor that sent it and keeps the first response
+-- until it expires. A row without a status_code belongs to a request that is still running.
+CREATE TABLE idempotency_key (
+    actor VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(16) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash BYTEA NOT NULL,
+    status_code INT,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (actor, key)
+);
+
+CREATE INDEX idempotency_key_expires_at_idx ON idempotency_key (expires_at);
@@ -0,0 +1,29 @@
+DELETE FROM idempotency_key;
+ALTER TABLE idempotency_key DROP CONSTRAINT idempotency_key_pkey;
+ALTER TABLE idempotency_key DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE idempotency_key ADD PRIMARY KEY (actor, key);
+
+DROP INDEX IF EXISTS audit_log_tenant_id_idx;
+DROP INDEX IF EXISTS order_header_tenant_id_idx;
+DROP INDEX IF EXISTS transaction_tenant_id_idx;
+
+ALTER TABLE order_header DROP CONSTRAINT IF EXISTS order_header_tenant_customer_fkey;
+ALTER TABLE transaction DROP CONSTRAINT IF EXISTS transaction_tenant_item_fkey;
+ALTER TABLE transaction DROP CONSTRAINT IF EXISTS transaction_tenant_customer_fkey;
+ALTER TABLE customer DROP CONSTRAINT IF EXISTS customer_tenant_id_id_key;
+ALTER TABLE item DROP CONSTRAINT IF EXISTS item_tenant_id_id_key;
+
+-- fails when two tenants use the same name, they have to be renamed first
+ALTER TABLE customer DROP CONSTRAINT IF EXISTS customer_tenant_id_customer_name_key;
+ALTER TABLE customer ADD CONSTRAINT customer_customer_name_key UNIQUE (customer_name);
+ALTER TABLE item DROP CONSTRAINT IF EXISTS item_tenant_id_item_name_key;
+ALTER TABLE item ADD CONSTRAINT item_item_name_key UNIQUE (item_name);
+
+ALTER TABLE api_key DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE app_user DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE order_header DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transaction DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE customer DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE item DROP COLUMN IF EXISTS tenant_id;
+DROP TABLE IF EXISTS tenant;
@@ -0,0 +1,54 @@
+-- Tenant migration, every shop gets its own items, customers, transactions, orders, users and api keys.
+-- The rows that exist already belong to the default tenant.
+CREATE TABLE tenant (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenant (slug, name) VALUES ('default', 'Default shop');
+
+ALTER TABLE item ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+ALTER TABLE customer ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+ALTER TABLE transaction ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+ALTER TABLE order_header ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+ALTER TABLE app_user ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+ALTER TABLE audit_log ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenant(id);
+-- a key without a tenant is a platform key, it picks the tenant with the X-Tenant-ID header
+ALTER TABLE api_key ADD COLUMN tenant_id INTEGER DEFAULT 1 REFERENCES tenant(id);
+
+-- new rows must name their tenant
+ALTER TABLE item ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE customer ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE transaction ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE order_header ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE app_user ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE audit_log ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_key ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- names are unique per tenant
+ALTER TABLE item DROP CONSTRAINT item_item_name_key;
+ALTER TABLE item ADD CONSTRAINT item_tenant_id_item_name_key UNIQUE (tenant_id, item_name);
+ALTER TABLE customer DROP CONSTRAINT customer_customer_name_key;
+ALTER TABLE customer ADD CONSTRAINT customer_tenant_id_customer_name_key UNIQUE (tenant_id, customer_name);
+
+-- a transaction or an order can only reference customers and items of its own tenant
+ALTER TABLE item ADD CONSTRAINT item_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE customer ADD CONSTRAINT customer_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE transaction ADD CONSTRAINT transaction_tenant_customer_fkey
+    FOREIGN KEY (tenant_id, customer_id) REFERENCES customer (tenant_id, id);
+ALTER TABLE transaction ADD CONSTRAINT transaction_tenant_item_fkey
+    FOREIGN KEY (tenant_id, item_id) REFERENCES item (tenant_id, id);
+ALTER TABLE order_header ADD CONSTRAINT order_header_tenant_customer_fkey
+    FOREIGN KEY (tenant_id, customer_id) REFERENCES customer (tenant_id, id);
+
+CREATE INDEX transaction_tenant_id_idx ON transaction (tenant_id, id);
+CREATE INDEX order_header_tenant_id_idx ON order_header (tenant_id, id);
+CREATE INDEX audit_log_tenant_id_idx ON audit_log (tenant_id, id);
+
+-- idempotency keys are scoped to the tenant as well, the stored ones are short lived and dropped
+DELETE FROM idempotency_key;
+ALTER TABLE idempotency_key ADD COLUMN tenant_id INTEGER NOT NULL REFERENCES tenant(id);
+ALTER TABLE idempotency_key DROP CONSTRAINT idempotency_key_pkey;
+ALTER TABLE idempotency_key ADD PRIMARY KEY (tenant_id, actor, key);
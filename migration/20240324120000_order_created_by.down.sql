@@ -0,0 +1 @@
+ALTER TABLE order_header DROP COLUMN IF EXISTS created_by;
@@ -0,0 +1,2 @@
+-- the actor, a user or an api key, that placed an order
+ALTER TABLE order_header ADD COLUMN created_by VARCHAR(255) NOT NULL DEFAULT '';
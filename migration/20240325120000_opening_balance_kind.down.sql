@@ -0,0 +1,2 @@
+-- the backfilled rows can't be told apart from the credits of new customers, they stay credits
+SELECT 1;
@@ -0,0 +1,5 @@
+-- opening balances are credits like the ones written when a customer is created or imported,
+-- the backfill of the balance_entry migration wrote them as adjustments before
+ALTER TABLE balance_entry DISABLE TRIGGER balance_entry_immutable;
+UPDATE balance_entry SET kind = 'credit' WHERE kind = 'adjustment' AND reason = 'opening balance';
+ALTER TABLE balance_entry ENABLE TRIGGER balance_entry_immutable;
@@ -0,0 +1,9 @@
+// Package migration embeds the SQL migrations so the binary can apply them without the sources.
+package migration
+
+import "embed"
+
+// FS holds the golang-migrate files VERSION_name.up.sql and VERSION_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS